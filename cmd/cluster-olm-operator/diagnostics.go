@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configclient "github.com/openshift/client-go/config/clientset/versioned"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/tools/clientcmd"
+
+	olmversion "github.com/openshift/cluster-olm-operator/pkg/version"
+)
+
+// operandImageEnvVars are the environment variables holding the operand
+// image references substituted into the operand manifests.
+var operandImageEnvVars = []string{
+	"CATALOGD_IMAGE",
+	"OPERATOR_CONTROLLER_IMAGE",
+	"KUBE_RBAC_PROXY_IMAGE",
+}
+
+// operandImages returns the operand image references keyed by environment variable.
+func operandImages(getenv func(string) string) map[string]string {
+	images := make(map[string]string, len(operandImageEnvVars))
+	for _, envVar := range operandImageEnvVars {
+		images[envVar] = getenv(envVar)
+	}
+	return images
+}
+
+func newDiagnosticsCommand() *cobra.Command {
+	var kubeconfig string
+
+	cmd := &cobra.Command{
+		Use:   "diagnostics",
+		Short: "Print the operator version, operand images and cluster feature set",
+		Long: `Print a read-only snapshot of the operator version, the operand image
+references and the feature set of the cluster FeatureGate, for use in bug reports.
+The feature set is looked up on a best-effort basis.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			featureGates, clientErr := featureGatesClient(kubeconfig)
+			return writeDiagnostics(cmd.Context(), cmd.OutOrStdout(), olmversion.Get(), os.Getenv, featureGates, clientErr)
+		},
+	}
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	return cmd
+}
+
+// featureGatesClient returns a FeatureGate client for the given kubeconfig, or the
+// in-cluster configuration when it is empty.
+func featureGatesClient(kubeconfig string) (configv1client.FeatureGatesGetter, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	configClient, err := configclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return configClient.ConfigV1(), nil
+}
+
+// writeDiagnostics writes the diagnostics report to w. A failure to build the
+// featureGates client, given as clientErr, or to read the cluster FeatureGate, is
+// reported as an unknown feature set along with its cause.
+func writeDiagnostics(ctx context.Context, w io.Writer, v version.Info, getenv func(string) string, featureGates configv1client.FeatureGatesGetter, clientErr error) error {
+	lines := []string{fmt.Sprintf("Operator version: %s", v.String())}
+	for _, envVar := range operandImageEnvVars {
+		lines = append(lines, fmt.Sprintf("%s: %s", envVar, getenv(envVar)))
+	}
+	lines = append(lines, fmt.Sprintf("Feature set: %s", featureSet(ctx, featureGates, clientErr)))
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func featureSet(ctx context.Context, featureGates configv1client.FeatureGatesGetter, clientErr error) string {
+	if clientErr != nil {
+		return fmt.Sprintf("unknown (%v)", clientErr)
+	}
+	if featureGates == nil {
+		return "unknown (no cluster connection)"
+	}
+	fg, err := featureGates.FeatureGates().Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+	if fg.Spec.FeatureSet == configv1.Default {
+		return "Default"
+	}
+	return string(fg.Spec.FeatureSet)
+}
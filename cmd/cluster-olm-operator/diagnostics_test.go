@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestWriteDiagnostics(t *testing.T) {
+	env := map[string]string{
+		"CATALOGD_IMAGE":            "quay.io/example/catalogd@sha256:1234",
+		"OPERATOR_CONTROLLER_IMAGE": "quay.io/example/operator-controller@sha256:5678",
+		"KUBE_RBAC_PROXY_IMAGE":     "quay.io/example/kube-rbac-proxy@sha256:9abc",
+	}
+	getenv := func(key string) string { return env[key] }
+	v := version.Info{GitVersion: "v4.18.0"}
+
+	for _, tc := range []struct {
+		name         string
+		featureGates configv1client.FeatureGatesGetter
+		clientErr    error
+		expected     string
+	}{
+		{
+			name: "tech preview feature set",
+			featureGates: configfake.NewSimpleClientset(&configv1.FeatureGate{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: configv1.FeatureGateSpec{
+					FeatureGateSelection: configv1.FeatureGateSelection{FeatureSet: configv1.TechPreviewNoUpgrade},
+				},
+			}).ConfigV1(),
+			expected: `Operator version: v4.18.0
+CATALOGD_IMAGE: quay.io/example/catalogd@sha256:1234
+OPERATOR_CONTROLLER_IMAGE: quay.io/example/operator-controller@sha256:5678
+KUBE_RBAC_PROXY_IMAGE: quay.io/example/kube-rbac-proxy@sha256:9abc
+Feature set: TechPreviewNoUpgrade
+`,
+		},
+		{
+			name: "default feature set",
+			featureGates: configfake.NewSimpleClientset(&configv1.FeatureGate{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			}).ConfigV1(),
+			expected: `Operator version: v4.18.0
+CATALOGD_IMAGE: quay.io/example/catalogd@sha256:1234
+OPERATOR_CONTROLLER_IMAGE: quay.io/example/operator-controller@sha256:5678
+KUBE_RBAC_PROXY_IMAGE: quay.io/example/kube-rbac-proxy@sha256:9abc
+Feature set: Default
+`,
+		},
+		{
+			name:         "feature gate missing",
+			featureGates: configfake.NewSimpleClientset().ConfigV1(),
+			expected: `Operator version: v4.18.0
+CATALOGD_IMAGE: quay.io/example/catalogd@sha256:1234
+OPERATOR_CONTROLLER_IMAGE: quay.io/example/operator-controller@sha256:5678
+KUBE_RBAC_PROXY_IMAGE: quay.io/example/kube-rbac-proxy@sha256:9abc
+Feature set: unknown (featuregates.config.openshift.io "cluster" not found)
+`,
+		},
+		{
+			name:      "no cluster connection",
+			clientErr: errors.New(`stat /missing/kubeconfig: no such file or directory`),
+			expected: `Operator version: v4.18.0
+CATALOGD_IMAGE: quay.io/example/catalogd@sha256:1234
+OPERATOR_CONTROLLER_IMAGE: quay.io/example/operator-controller@sha256:5678
+KUBE_RBAC_PROXY_IMAGE: quay.io/example/kube-rbac-proxy@sha256:9abc
+Feature set: unknown (stat /missing/kubeconfig: no such file or directory)
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeDiagnostics(context.Background(), &out, v, getenv, tc.featureGates, tc.clientErr); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out.String() != tc.expected {
+				t.Errorf("expected output:\n%s\ngot:\n%s", tc.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestFeatureGatesClientError(t *testing.T) {
+	if _, err := featureGatesClient(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing kubeconfig")
+	}
+}
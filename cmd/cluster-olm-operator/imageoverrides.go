@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/go-logr/logr"
+)
+
+// imageReferenceRegexp matches an image reference: an optional registry host and
+// port, a lowercase repository path, then an optional tag and an optional digest.
+var imageReferenceRegexp = regexp.MustCompile(`^` +
+	`(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	`(?::[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?` +
+	`(?:@[a-zA-Z][a-zA-Z0-9]*(?:[-_+.][a-zA-Z][a-zA-Z0-9]*)*:[0-9a-fA-F]{32,})?` +
+	`$`)
+
+// imageOverrides holds the operand images set through flags, which take precedence
+// over those of the environment, e.g. to run a debug build of a single operand.
+type imageOverrides struct {
+	catalogd           string
+	operatorController string
+}
+
+// apply validates the overrides and sets them on the image environment variables the
+// operand manifests are rendered from.
+func (o imageOverrides) apply(logger logr.Logger, getenv func(string) string, setenv func(string, string) error) error {
+	var errs []error
+	for _, override := range []struct {
+		flag   string
+		envVar string
+		image  string
+	}{
+		{flag: "catalogd-image", envVar: "CATALOGD_IMAGE", image: o.catalogd},
+		{flag: "operator-controller-image", envVar: "OPERATOR_CONTROLLER_IMAGE", image: o.operatorController},
+	} {
+		if override.image == "" {
+			continue
+		}
+		if !imageReferenceRegexp.MatchString(override.image) {
+			errs = append(errs, fmt.Errorf("invalid --%s %q: not a valid image reference", override.flag, override.image))
+			continue
+		}
+		logger.Info("overriding the operand image", "envVar", override.envVar, "image", override.image, "environmentImage", getenv(override.envVar))
+		if err := setenv(override.envVar, override.image); err != nil {
+			errs = append(errs, fmt.Errorf("setting %s: %w", override.envVar, err))
+		}
+	}
+	return errors.Join(errs...)
+}
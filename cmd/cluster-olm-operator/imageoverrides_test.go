@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestImageOverridesApply(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		overrides   imageOverrides
+		expectedEnv map[string]string
+		expectError bool
+	}{
+		{
+			name: "no overrides keep the environment",
+			expectedEnv: map[string]string{
+				"CATALOGD_IMAGE":            "quay.io/openshift/catalogd:4.18",
+				"OPERATOR_CONTROLLER_IMAGE": "quay.io/openshift/operator-controller:4.18",
+			},
+		},
+		{
+			name:      "the flag takes precedence over the environment",
+			overrides: imageOverrides{catalogd: "quay.io/dev/catalogd:debug"},
+			expectedEnv: map[string]string{
+				"CATALOGD_IMAGE":            "quay.io/dev/catalogd:debug",
+				"OPERATOR_CONTROLLER_IMAGE": "quay.io/openshift/operator-controller:4.18",
+			},
+		},
+		{
+			name: "both overridden",
+			overrides: imageOverrides{
+				catalogd:           "localhost:5000/catalogd",
+				operatorController: "registry.example.com/olm/operator-controller@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			},
+			expectedEnv: map[string]string{
+				"CATALOGD_IMAGE":            "localhost:5000/catalogd",
+				"OPERATOR_CONTROLLER_IMAGE": "registry.example.com/olm/operator-controller@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			},
+		},
+		{
+			name:        "invalid reference",
+			overrides:   imageOverrides{operatorController: "Quay.io/Dev/Operator-Controller:debug build"},
+			expectError: true,
+		},
+		{
+			name:        "scheme is not part of a reference",
+			overrides:   imageOverrides{catalogd: "https://quay.io/dev/catalogd"},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := map[string]string{
+				"CATALOGD_IMAGE":            "quay.io/openshift/catalogd:4.18",
+				"OPERATOR_CONTROLLER_IMAGE": "quay.io/openshift/operator-controller:4.18",
+			}
+			getenv := func(key string) string { return env[key] }
+			setenv := func(key, value string) error {
+				env[key] = value
+				return nil
+			}
+
+			err := tc.overrides.apply(logr.Discard(), getenv, setenv)
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expectedEnv, env) {
+				t.Errorf("expected the environment %v, got %v", tc.expectedEnv, env)
+			}
+		})
+	}
+}
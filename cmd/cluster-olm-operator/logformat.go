@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-logr/logr"
+	jsonlogs "k8s.io/component-base/logs/json"
+	"k8s.io/klog/v2"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	// jsonLoggerVerbosity is the verbosity of the underlying JSON logger. Verbosity is
+	// actually enforced by klogVerbositySink so that it keeps following the klog -v
+	// flag, which the OLM resource log level updates at runtime.
+	jsonLoggerVerbosity = 127
+)
+
+// newLogger returns the logger the operator logs through for the given format, or
+// false when klog's own text output is used.
+func newLogger(format string, out io.Writer) (logr.Logger, func(), bool, error) {
+	switch format {
+	case logFormatText:
+		return logr.Logger{}, nil, false, nil
+	case logFormatJSON:
+		logger, control := jsonlogs.NewJSONLogger(jsonLoggerVerbosity, jsonlogs.AddNopSync(out), nil, nil)
+		return logr.New(klogVerbositySink{LogSink: logger.GetSink()}), control.Flush, true, nil
+	default:
+		return logr.Logger{}, nil, false, fmt.Errorf("unsupported log format %q, must be one of %q or %q", format, logFormatText, logFormatJSON)
+	}
+}
+
+// configureLogFormat routes the klog output, including that of the contextual
+// loggers, through the logger of the given format.
+func configureLogFormat(format string) error {
+	logger, flush, ok, err := newLogger(format, os.Stderr)
+	if err != nil || !ok {
+		return err
+	}
+	klog.SetLoggerWithOptions(logger, klog.ContextualLogger(true), klog.FlushLogger(flush))
+	return nil
+}
+
+// klogVerbositySink only enables the info logs allowed by the current klog verbosity.
+type klogVerbositySink struct {
+	logr.LogSink
+}
+
+func (s klogVerbositySink) Enabled(level int) bool {
+	return klog.V(klog.Level(level)).Enabled() && s.LogSink.Enabled(level)
+}
+
+func (s klogVerbositySink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return klogVerbositySink{LogSink: s.LogSink.WithValues(keysAndValues...)}
+}
+
+func (s klogVerbositySink) WithName(name string) logr.LogSink {
+	return klogVerbositySink{LogSink: s.LogSink.WithName(name)}
+}
+
+func (s klogVerbositySink) WithCallDepth(depth int) logr.LogSink {
+	if sink, ok := s.LogSink.(logr.CallDepthLogSink); ok {
+		return klogVerbositySink{LogSink: sink.WithCallDepth(depth)}
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		format      string
+		expectJSON  bool
+		expectError bool
+	}{
+		{
+			name:   "text uses the klog output",
+			format: logFormatText,
+		},
+		{
+			name:       "json",
+			format:     logFormatJSON,
+			expectJSON: true,
+		},
+		{
+			name:        "unsupported format",
+			format:      "logfmt",
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger, flush, ok, err := newLogger(tc.format, &out)
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != tc.expectJSON {
+				t.Fatalf("expected a dedicated logger to be %t, got %t", tc.expectJSON, ok)
+			}
+			if !ok {
+				return
+			}
+
+			logger.WithName("FeatureGates").Info("feature gates", "enabled", []string{"A", "B"})
+			// the default klog verbosity disables the debug logs
+			logger.V(4).Info("sync started")
+			flush()
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+				t.Fatalf("expected a single JSON log entry, got %q: %v", out.String(), err)
+			}
+			if entry["msg"] != "feature gates" || entry["logger"] != "FeatureGates" {
+				t.Errorf("unexpected log entry %v", entry)
+			}
+		})
+	}
+}
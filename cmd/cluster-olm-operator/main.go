@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	goflag "flag"
 	"fmt"
 	"os"
@@ -18,13 +19,20 @@ import (
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/component-base/cli"
 	utilflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift/cluster-olm-operator/internal/utils"
 	"github.com/openshift/cluster-olm-operator/pkg/clients"
@@ -66,25 +74,109 @@ func newRootCommand() *cobra.Command {
 	}
 	cmd.PersistentFlags().BoolVarP(&versionFlag, "version", "V", false, "Print the version number and exit")
 	cmd.AddCommand(newStartCommand())
+	cmd.AddCommand(newDiagnosticsCommand())
 	return cmd
 }
 
+type startOptions struct {
+	deleteStaticResourcesOnRemoval bool
+	additionalInformerNamespaces   []string
+	operandConfigPath              string
+	maxListedIncompatibleOperators int
+	reportOnlyClusterCatalogs      []string
+	observeOnly                    bool
+	slowSyncThreshold              time.Duration
+	retiredFinalizers              []string
+	incompatibleGracePeriod        time.Duration
+	clusterCatalogRolloutTimeout   time.Duration
+	nonForcedStaticResourceKinds   []string
+	servePreflight                 bool
+	applyLogVerbosity              int
+	checkResourcePermissions       bool
+	excludedManifests              []string
+	logFormat                      string
+	preferClusterVersion           bool
+	workers                        controllerWorkers
+	images                         imageOverrides
+	releaseImageReferencesPath     string
+	ensureOperandPDBs              bool
+}
+
+func (o *startOptions) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.deleteStaticResourcesOnRemoval, "delete-static-resources-on-removal", false, "Delete the non-CRD static resources managed by the operator when the OLM resource is set to Removed. CRDs are always retained.")
+	fs.StringSliceVar(&o.additionalInformerNamespaces, "additional-informer-namespaces", nil, "Comma-separated list of namespaces to watch in addition to those of the rendered operand manifests.")
+	fs.StringVar(&o.operandConfigPath, "operand-config", "", "Path to a YAML file of per-component overrides applied to the operand Deployments and ClusterCatalogs, keyed by component name, either catalogd or operator-controller.")
+	fs.StringSliceVar(&o.reportOnlyClusterCatalogs, "report-only-clustercatalogs", nil, "Comma-separated list of managed ClusterCatalogs whose drift from their manifest is reported through events instead of being reverted.")
+	fs.BoolVar(&o.observeOnly, "observe-only", false, "Only compute and report status, including incompatible operators, without managing any operand resources. Operands are neither installed, updated, nor removed in this mode.")
+	fs.DurationVar(&o.slowSyncThreshold, "slow-sync-threshold", controller.DefaultSlowSyncThreshold, "Duration above which a single controller sync is logged as slow. Zero disables the warning.")
+	fs.IntVar(&o.applyLogVerbosity, "apply-log-verbosity", 0, "When positive, log every applied manifest, with Secret values redacted, at this klog verbosity. Zero disables the apply logs.")
+	fs.StringSliceVar(&o.nonForcedStaticResourceKinds, "non-forced-static-resource-kinds", nil, "Comma-separated list of static resource kinds, e.g. ClusterRole, that are only created when missing so that changes made to them are not reverted. CustomResourceDefinitions are always enforced.")
+	fs.StringSliceVar(&o.excludedManifests, "excluded-manifests", nil, "Comma-separated list of glob patterns matched against the operand asset paths, e.g. operator-controller/*-deployment.yaml. No controller manages the matching manifests.")
+	fs.StringSliceVar(&o.retiredFinalizers, "retired-finalizers", nil, "Comma-separated list of finalizers set by previous operator versions to remove from the OLM resource at startup. Ignored in observe-only mode.")
+	fs.DurationVar(&o.incompatibleGracePeriod, "incompatible-operators-grace-period", 0, "Duration incompatible operators must persist before InstalledOLMOperatorsUpgradeable is set to False, to avoid flapping on transient states.")
+	fs.DurationVar(&o.clusterCatalogRolloutTimeout, "clustercatalog-rollout-gate-timeout", 0, "When set, hold the rollout of the operator-controller Deployments until the managed ClusterCatalogs are serving, for at most this duration.")
+	fs.BoolVar(&o.servePreflight, "serve-preflight", false, "Serve, on the operator secure port, a read-only /preflight?version=<major.minor> endpoint reporting the installed operators that would block an upgrade to that version, and a /scan?version=<major.minor> endpoint dumping the outcome for every installed operator. Requests are authenticated and authorized against the API server: clients need the get verb on the /preflight and /scan non-resource URLs.")
+	fs.BoolVar(&o.checkResourcePermissions, "check-resource-permissions", false, "Verify through SelfSubjectAccessReviews that the operator may apply every rendered resource, reporting missing permissions through the ResourcePermissionsDegraded condition.")
+	fs.BoolVar(&o.preferClusterVersion, "prefer-clusterversion-ocp-version", false, "Determine the OpenShift version from the desired version of the ClusterVersion rather than from the operator image version. Either source is used as a fallback when the other is missing or invalid.")
+	fs.StringVar(&o.logFormat, "log-format", logFormatText, "Log output format, either text or json.")
+	fs.IntVar(&o.workers.reporting, "reporting-controller-workers", 1, "Number of workers of each controller that only reports status, such as the incompatible operators controller.")
+	fs.IntVar(&o.workers.managing, "managing-controller-workers", 1, "Number of workers of each controller managing the operator and static operand resources, such as the static resource controllers.")
+	fs.IntVar(&o.workers.dependent, "dependent-controller-workers", 1, "Number of workers of each controller managing the operand Deployments and ClusterCatalogs.")
+	fs.StringVar(&o.images.catalogd, "catalogd-image", "", "When set, the catalogd image, overriding the CATALOGD_IMAGE environment variable.")
+	fs.StringVar(&o.images.operatorController, "operator-controller-image", "", "When set, the operator-controller image, overriding the OPERATOR_CONTROLLER_IMAGE environment variable.")
+	fs.StringVar(&o.releaseImageReferencesPath, "release-image-references", "", "When set, path to the release image references ImageStream listing the approved operand images. Operand images differing from it are reported through the OperandImagesDegraded condition.")
+	fs.BoolVar(&o.ensureOperandPDBs, "ensure-operand-pdbs", false, "Ensure operand Deployments running more than one replica have a PodDisruptionBudget letting at most one pod be evicted at a time, unless their manifests include one.")
+	fs.IntVar(&o.maxListedIncompatibleOperators, "max-listed-incompatible-operators", controller.DefaultMaxListedIncompatibleOperators, "Maximum number of incompatible operators named in the upgradeable condition message; the rest are summarized. Zero or less lists all of them.")
+}
+
 func newStartCommand() *cobra.Command {
+	opts := &startOptions{}
 	cmd := controllercmd.NewControllerCommandConfig(
 		"cluster-olm-operator",
 		version.Get(),
-		runOperator,
+		opts.runOperator,
 	).NewCommandWithContext(context.Background())
 	cmd.Use = "start"
 	cmd.Short = "Start the Cluster OLM Operator"
+	cmd.PreRunE = func(*cobra.Command, []string) error {
+		return configureLogFormat(opts.logFormat)
+	}
+	opts.addFlags(cmd.Flags())
 	return cmd
 }
 
-func runOperator(ctx context.Context, cc *controllercmd.ControllerContext) error {
+func (o *startOptions) runOperator(ctx context.Context, cc *controllercmd.ControllerContext) error {
+	if err := o.workers.validate(); err != nil {
+		return err
+	}
+	if err := o.images.apply(klog.FromContext(ctx).WithName("main"), os.Getenv, os.Setenv); err != nil {
+		return err
+	}
+	timer := newStartupTimer(klog.FromContext(ctx).WithName("startup"), clock.RealClock{})
+
 	cl, err := clients.New(cc)
 	if err != nil {
 		return err
 	}
+	timer.phaseDone("clients")
+
+	var operandConfigs controller.OperandConfigs
+	if o.operandConfigPath != "" {
+		operandConfigs, err = controller.LoadOperandConfigs(o.operandConfigPath, "catalogd", "operator-controller")
+		if err != nil {
+			return err
+		}
+	}
+
+	var releaseImages map[string]string
+	if o.releaseImageReferencesPath != "" {
+		releaseImages, err = controller.LoadReleaseImages(o.releaseImageReferencesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	operatorImageVersion := status.VersionForOperatorFromEnv()
 
 	clusterCatalogGvk := catalogdv1.GroupVersion.WithKind("ClusterCatalog")
 	cb := controller.Builder{
@@ -98,19 +190,52 @@ func runOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 				Scope:            meta.RESTScopeRoot,
 			},
 		},
+		DeleteStaticResourcesOnRemoval: o.deleteStaticResourcesOnRemoval,
+		OperandConfigs:                 operandConfigs,
+		OperatorVersion:                operatorImageVersion,
+		ReportOnlyClusterCatalogs:      sets.New(o.reportOnlyClusterCatalogs...),
+		ClusterCatalogRolloutTimeout:   o.clusterCatalogRolloutTimeout,
+		// catalogd serves the ClusterCatalogs, so only operator-controller can wait for them
+		ClusterCatalogGatedComponents: sets.New("operator-controller"),
+		NonForcedStaticResourceKinds:  sets.New(o.nonForcedStaticResourceKinds...),
+		ExcludedManifests:             o.excludedManifests,
+		SlowSyncThreshold:             o.slowSyncThreshold,
+		ApplyLogVerbosity:             o.applyLogVerbosity,
+	}
+
+	if err := cb.Validate("catalogd", "operator-controller"); err != nil {
+		return err
 	}
+	timer.phaseDone("validate")
+
+	operandNamespaces, err := cb.DeploymentNamespaces("catalogd", "operator-controller")
+	if err != nil {
+		return err
+	}
+	cl.OperandKubeInformersForNamespaces = v1helpers.NewKubeInformersForNamespaces(cl.KubeClient, sets.List(operandNamespaces)...)
 
 	staticResourceControllers, deploymentControllers, clusterCatalogControllers, relatedObjects, err := cb.BuildControllers("catalogd", "operator-controller")
 	if err != nil {
 		return err
 	}
 
-	namespaces := sets.New[string]()
-	for _, obj := range relatedObjects {
-		namespaces.Insert(obj.Namespace)
+	helmReleaseNamespace, err := cb.DeploymentNamespace("operator-controller")
+	if err != nil {
+		return err
 	}
 
-	cl.KubeInformersForNamespaces = v1helpers.NewKubeInformersForNamespaces(cl.KubeClient, namespaces.UnsortedList()...)
+	operandHash, err := cb.OperandHash("catalogd", "operator-controller")
+	if err != nil {
+		return err
+	}
+
+	clusterCatalogImages, err := cb.ClusterCatalogImages("catalogd", "operator-controller")
+	if err != nil {
+		return err
+	}
+	timer.phaseDone("build")
+
+	cl.KubeInformersForNamespaces = v1helpers.NewKubeInformersForNamespaces(cl.KubeClient, informerNamespaces(relatedObjects, o.additionalInformerNamespaces)...)
 
 	controllerNames := make([]string, 0, len(staticResourceControllers)+len(deploymentControllers))
 	staticResourceControllerList := make([]factory.Controller, 0, len(staticResourceControllers))
@@ -132,8 +257,22 @@ func runOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		clusterCatalogControllerList = append(clusterCatalogControllerList, controller)
 	}
 
-	operatorImageVersion := status.VersionForOperatorFromEnv()
-	nextOCPMinorVersion, err := utils.GetNextOCPMinorVersion(operatorImageVersion)
+	ocpVersion, err := utils.ResolveOCPVersion(operatorImageVersion, func() (string, error) {
+		clusterVersion, err := cl.ConfigClient.ConfigV1().ClusterVersions().Get(ctx, "version", metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return clusterVersion.Status.Desired.Version, nil
+	}, o.preferClusterVersion)
+	if err != nil {
+		return err
+	}
+	timer.phaseDone("ocpVersion")
+	if ocpVersion != operatorImageVersion {
+		klog.FromContext(ctx).WithName("main").Info("using the ClusterVersion desired version as the OpenShift version", "version", ocpVersion, "operatorImageVersion", operatorImageVersion)
+	}
+
+	nextOCPMinorVersion, err := utils.GetNextOCPMinorVersion(ocpVersion)
 	if err != nil {
 		return err
 	}
@@ -143,15 +282,21 @@ func runOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		cl.OperatorClient,
 		cc.EventRecorder.ForComponent("OLMStaticUpgradeableConditionController"),
 		controllerNames,
+		o.slowSyncThreshold,
 	)
 
 	incompatibleOperatorController := controller.NewIncompatibleOperatorController(
 		"OLMIncompatibleOperatorController",
 		nextOCPMinorVersion,
+		cl.ConfigInformerFactory.Config().V1().ClusterVersions(),
 		cl.KubeClient,
 		cl.ClusterExtensionClient,
 		cl.OperatorClient,
+		helmReleaseNamespace,
+		o.maxListedIncompatibleOperators,
+		o.incompatibleGracePeriod,
 		cc.EventRecorder.ForComponent("OLMIncompatibleOperatorController"),
+		o.slowSyncThreshold,
 	)
 
 	// Update the environment if proxy information is available
@@ -165,8 +310,136 @@ func runOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		cl.ProxyClient,
 		cl.OperatorClient,
 		cc.EventRecorder.ForComponent(olmProxyController),
+		o.slowSyncThreshold,
+	)
+
+	proxyTrustedCAController := controller.NewProxyTrustedCAController(
+		"OLMProxyTrustedCAController",
+		deploymentNamespaces(relatedObjects),
+		cl.ProxyClient,
+		cl.KubeClient.CoreV1(),
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMProxyTrustedCAController"),
+		o.slowSyncThreshold,
+	)
+
+	clusterCatalogStatusController := controller.NewClusterCatalogStatusController(
+		"OLMClusterCatalogStatusController",
+		clusterCatalogNames(relatedObjects),
+		cl.ClusterCatalogClient,
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMClusterCatalogStatusController"),
+		o.slowSyncThreshold,
+	)
+
+	clusterCatalogImagePolicyController := controller.NewClusterCatalogImagePolicyController(
+		"OLMClusterCatalogImagePolicyController",
+		clusterCatalogImages,
+		cl.ConfigInformerFactory.Config().V1().Images(),
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMClusterCatalogImagePolicyController"),
+		o.slowSyncThreshold,
+	)
+
+	renderWarningsController := controller.NewRenderWarningsController(
+		"OLMRenderWarningsController",
+		cb.RenderWarnings(),
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMRenderWarningsController"),
+		o.slowSyncThreshold,
 	)
 
+	effectiveConfigController := controller.NewEffectiveConfigController(
+		"OLMEffectiveConfigController",
+		newNamespaceObjectReference().Name,
+		controller.EffectiveConfig{
+			OperatorVersion:      operatorImageVersion,
+			OCPVersion:           ocpVersion,
+			NextOCPMinorVersion:  nextOCPMinorVersion.String(),
+			OperandImages:        operandImages(os.Getenv),
+			ClusterCatalogImages: clusterCatalogImages,
+			ResyncPeriod:         clients.DefaultResyncPeriod.String(),
+			ObserveOnly:          o.observeOnly,
+			OperandConfigPath:    o.operandConfigPath,
+			ExcludedManifests:    o.excludedManifests,
+			RenderWarnings:       cb.RenderWarnings(),
+		},
+		cl.KubeClient.CoreV1(),
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMEffectiveConfigController"),
+		o.slowSyncThreshold,
+	)
+
+	crdEstablishedController := controller.NewCRDEstablishedController(
+		"OLMCRDEstablishedController",
+		crdNames(relatedObjects),
+		cl.APIExtensionsClient,
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMCRDEstablishedController"),
+		o.slowSyncThreshold,
+	)
+
+	operandDowngradeController := controller.NewOperandDowngradeController(
+		"OLMOperandDowngradeController",
+		operatorImageVersion,
+		deploymentNames(relatedObjects),
+		cl.KubeInformerFactory.Apps().V1().Deployments(),
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMOperandDowngradeController"),
+		o.slowSyncThreshold,
+	)
+
+	operandLeaseCleanupController := controller.NewOperandLeaseCleanupController(
+		"OLMOperandLeaseCleanupController",
+		deploymentNames(relatedObjects),
+		controller.OperandLeaderElectionLocks,
+		cl.KubeInformerFactory.Apps().V1().Deployments(),
+		cl.KubeClient.CoordinationV1(),
+		cl.KubeClient.CoreV1(),
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMOperandLeaseCleanupController"),
+		o.slowSyncThreshold,
+	)
+
+	var operandPDBController factory.Controller
+	if o.ensureOperandPDBs {
+		operandPDBController = controller.NewOperandPDBController(
+			"OLMOperandPDBController",
+			deploymentNames(relatedObjects),
+			pdbNamespaces(relatedObjects),
+			cl.KubeInformerFactory.Apps().V1().Deployments(),
+			cl.KubeClient.PolicyV1(),
+			cl.OperatorClient,
+			cc.EventRecorder.ForComponent("OLMOperandPDBController"),
+			o.slowSyncThreshold,
+		)
+	}
+
+	// check the rendered resources only, before the OLM resource and the operator namespace are added below
+	var resourcePermissionsController factory.Controller
+	if o.checkResourcePermissions {
+		resourcePermissionsController = controller.NewResourcePermissionsController(
+			"OLMResourcePermissionsController",
+			relatedObjects,
+			cl.KubeClient.AuthorizationV1(),
+			cl.OperatorClient,
+			cc.EventRecorder.ForComponent("OLMResourcePermissionsController"),
+			o.slowSyncThreshold,
+		)
+	}
+
+	var operandImagesController factory.Controller
+	if releaseImages != nil {
+		operandImagesController = controller.NewOperandImagesController(
+			"OLMOperandImagesController",
+			releaseImages,
+			operandImages(os.Getenv),
+			cl.OperatorClient,
+			cc.EventRecorder.ForComponent("OLMOperandImagesController"),
+			o.slowSyncThreshold,
+		)
+	}
+
 	versionGetter := status.NewVersionGetter()
 	versionGetter.SetVersion("operator", status.VersionForOperatorFromEnv())
 
@@ -187,38 +460,291 @@ func runOperator(ctx context.Context, cc *controllercmd.ControllerContext) error
 		versionGetter,
 		cc.EventRecorder.ForComponent("olm"),
 	)
+	clusterOperatorController.WithRelatedObjectsFunc(controller.NewOperandNamespacesRelatedObjectsFunc(cl.KubeInformerFactory.Core().V1().Namespaces()))
+
+	provenanceController := controller.NewClusterOperatorProvenanceController(
+		"OLMClusterOperatorProvenanceController",
+		"olm",
+		operatorImageVersion,
+		operandHash,
+		cl.ConfigClient.ConfigV1(),
+		cl.ConfigInformerFactory.Config().V1().ClusterOperators(),
+		cl.OperatorClient,
+		cc.EventRecorder.ForComponent("OLMClusterOperatorProvenanceController"),
+		o.slowSyncThreshold,
+	)
 
 	operatorLoggingController := loglevel.NewClusterOperatorLoggingController(cl.OperatorClient, cc.EventRecorder.ForComponent("ClusterOLMOperatorLoggingController"))
 
+	timer.phaseDone("controllers")
+
 	cl.StartInformers(ctx)
 
-	for _, c := range append(staticResourceControllerList, upgradeableConditionController, incompatibleOperatorController, clusterOperatorController, operatorLoggingController, proxyController) {
-		go func(c factory.Controller) {
-			defer runtime.HandleCrash()
-			c.Run(ctx, 1)
-		}(c)
+	// the finalizers are removed from the OLM resource, which is managed, not observed
+	if !o.observeOnly && len(o.retiredFinalizers) > 0 && cache.WaitForCacheSync(ctx.Done(), cl.OperatorClient.Informer().HasSynced) {
+		if err := controller.RemoveRetiredFinalizers(ctx, cl.OperatorClient, o.retiredFinalizers); err != nil {
+			klog.FromContext(ctx).WithName("main").Error(err, "failed to remove retired finalizers")
+		}
 	}
 
-	time.Sleep(10 * time.Second)
+	if o.servePreflight {
+		if cc.Server == nil {
+			return errors.New("--serve-preflight requires the operator secure server, which is disabled")
+		}
+		logger := klog.FromContext(ctx).WithName("preflight")
+		// the secure server authenticates and authorizes the requests before they are handled
+		cc.Server.Handler.NonGoRestfulMux.Handle("/preflight", controller.NewIncompatibleOperatorsPreflightHandler(cl.DynamicClient, cl.KubeClient, helmReleaseNamespace, logger))
+		cc.Server.Handler.NonGoRestfulMux.Handle("/scan", controller.NewIncompatibleOperatorsScanHandler(cl.DynamicClient, cl.KubeClient, helmReleaseNamespace, logger))
+	}
 
-	for _, c := range deploymentControllerList {
-		go func(c factory.Controller) {
-			defer runtime.HandleCrash()
-			c.Run(ctx, 1)
-		}(c)
+	timer.phaseDone("informers")
+	timer.summary()
+
+	controllers := operatorControllers{
+		staticResources:           staticResourceControllerList,
+		deployments:               deploymentControllerList,
+		clusterCatalogs:           clusterCatalogControllerList,
+		upgradeableCondition:      upgradeableConditionController,
+		operatorLogging:           operatorLoggingController,
+		operandLeaseCleanup:       operandLeaseCleanupController,
+		proxyTrustedCA:            proxyTrustedCAController,
+		resourcePermissions:       resourcePermissionsController,
+		operandPDB:                operandPDBController,
+		incompatibleOperator:      incompatibleOperatorController,
+		clusterOperator:           clusterOperatorController,
+		proxy:                     proxyController,
+		renderWarnings:            renderWarningsController,
+		effectiveConfig:           effectiveConfigController,
+		clusterCatalogStatus:      clusterCatalogStatusController,
+		clusterCatalogImagePolicy: clusterCatalogImagePolicyController,
+		crdEstablished:            crdEstablishedController,
+		operandDowngrade:          operandDowngradeController,
+		provenance:                provenanceController,
+		operandImages:             operandImagesController,
+	}.set()
+	immediate, delayed := controllers.toStart(o.observeOnly, o.workers)
+	if o.observeOnly {
+		klog.FromContext(ctx).WithName("main").Info("running in observe-only mode, operand resources are not managed")
 	}
 
-	for _, c := range clusterCatalogControllerList {
-		go func(c factory.Controller) {
-			defer runtime.HandleCrash()
-			c.Run(ctx, 1)
-		}(c)
+	startControllers(ctx, immediate)
+
+	if len(delayed) > 0 {
+		time.Sleep(10 * time.Second)
+
+		startControllers(ctx, delayed)
 	}
 
 	<-ctx.Done()
 	return nil
 }
 
+// controllerSet groups the controllers run by the operator by what they act on.
+type controllerSet struct {
+	// reporting controllers only compute and report status.
+	reporting []factory.Controller
+	// managing controllers manage the operator and static operand resources.
+	managing []factory.Controller
+	// dependent controllers manage operand resources relying on the CRDs applied
+	// by the static resource controllers, so they are started last.
+	dependent []factory.Controller
+}
+
+// operatorControllers holds every controller run by the operator. The optional ones
+// are nil when disabled.
+type operatorControllers struct {
+	staticResources []factory.Controller
+	deployments     []factory.Controller
+	clusterCatalogs []factory.Controller
+
+	upgradeableCondition factory.Controller
+	operatorLogging      factory.Controller
+	operandLeaseCleanup  factory.Controller
+	proxyTrustedCA       factory.Controller
+	resourcePermissions  factory.Controller
+	operandPDB           factory.Controller
+
+	incompatibleOperator      factory.Controller
+	clusterOperator           factory.Controller
+	proxy                     factory.Controller
+	renderWarnings            factory.Controller
+	effectiveConfig           factory.Controller
+	clusterCatalogStatus      factory.Controller
+	clusterCatalogImagePolicy factory.Controller
+	crdEstablished            factory.Controller
+	operandDowngrade          factory.Controller
+	provenance                factory.Controller
+	operandImages             factory.Controller
+}
+
+// set groups the controllers by what they act on. The controllers that only read
+// the cluster state and report it through conditions are reporting ones, so they
+// also run in observe-only mode.
+func (c operatorControllers) set() controllerSet {
+	return controllerSet{
+		reporting: nonNil(
+			c.incompatibleOperator,
+			c.clusterOperator,
+			c.proxy,
+			c.renderWarnings,
+			c.effectiveConfig,
+			c.clusterCatalogStatus,
+			c.clusterCatalogImagePolicy,
+			c.crdEstablished,
+			c.operandDowngrade,
+			c.provenance,
+			c.operandImages,
+		),
+		managing: append(append([]factory.Controller{}, c.staticResources...), nonNil(
+			c.upgradeableCondition,
+			c.operatorLogging,
+			c.operandLeaseCleanup,
+			c.proxyTrustedCA,
+			c.resourcePermissions,
+			c.operandPDB,
+		)...),
+		dependent: append(append([]factory.Controller{}, c.deployments...), c.clusterCatalogs...),
+	}
+}
+
+// nonNil returns the controllers that are set.
+func nonNil(controllers ...factory.Controller) []factory.Controller {
+	var set []factory.Controller
+	for _, c := range controllers {
+		if c != nil {
+			set = append(set, c)
+		}
+	}
+	return set
+}
+
+// controllerWorkers holds the number of workers each controller of a category of
+// the controllerSet is run with.
+type controllerWorkers struct {
+	reporting int
+	managing  int
+	dependent int
+}
+
+func (w controllerWorkers) validate() error {
+	var errs []error
+	for _, category := range []struct {
+		name    string
+		workers int
+	}{
+		{name: "reporting", workers: w.reporting},
+		{name: "managing", workers: w.managing},
+		{name: "dependent", workers: w.dependent},
+	} {
+		if category.workers < 1 {
+			errs = append(errs, fmt.Errorf("invalid number of %s controller workers %d: must be at least 1", category.name, category.workers))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scheduledController is a controller along with the number of workers it is run with.
+type scheduledController struct {
+	controller factory.Controller
+	workers    int
+}
+
+func schedule(controllers []factory.Controller, workers int) []scheduledController {
+	scheduled := make([]scheduledController, 0, len(controllers))
+	for _, c := range controllers {
+		scheduled = append(scheduled, scheduledController{controller: c, workers: workers})
+	}
+	return scheduled
+}
+
+// toStart returns the controllers to start right away and those to start once
+// the static resources had time to be applied. In observe-only mode only the
+// reporting controllers are started and no operand resource is managed.
+func (s controllerSet) toStart(observeOnly bool, workers controllerWorkers) (immediate, delayed []scheduledController) {
+	if observeOnly {
+		return schedule(s.reporting, workers.reporting), nil
+	}
+	return append(schedule(s.managing, workers.managing), schedule(s.reporting, workers.reporting)...), schedule(s.dependent, workers.dependent)
+}
+
+// startControllers runs each controller in its own goroutine until ctx is done.
+func startControllers(ctx context.Context, controllers []scheduledController) {
+	for _, c := range controllers {
+		go func(c scheduledController) {
+			defer runtime.HandleCrash()
+			c.controller.Run(ctx, c.workers)
+		}(c)
+	}
+}
+
+// informerNamespaces returns the namespaces of the given related objects
+// unioned with any additionally configured namespaces
+func informerNamespaces(relatedObjects []configv1.ObjectReference, additional []string) []string {
+	namespaces := sets.New[string](additional...)
+	for _, obj := range relatedObjects {
+		namespaces.Insert(obj.Namespace)
+	}
+	return sets.List(namespaces)
+}
+
+// clusterCatalogNames returns the names of the ClusterCatalogs
+// present in the given related objects
+func clusterCatalogNames(relatedObjects []configv1.ObjectReference) []string {
+	var names []string
+	for _, obj := range relatedObjects {
+		if obj.Group == catalogdv1.GroupVersion.Group && obj.Resource == "clustercatalogs" {
+			names = append(names, obj.Name)
+		}
+	}
+	return names
+}
+
+// crdNames returns the names of the CustomResourceDefinitions
+// present in the given related objects
+func crdNames(relatedObjects []configv1.ObjectReference) []string {
+	var names []string
+	for _, obj := range relatedObjects {
+		if obj.Group == apiextensionsv1.GroupName && obj.Resource == "customresourcedefinitions" {
+			names = append(names, obj.Name)
+		}
+	}
+	return names
+}
+
+// deploymentNames returns the namespaced names of the Deployments
+// present in the given related objects
+func deploymentNames(relatedObjects []configv1.ObjectReference) []types.NamespacedName {
+	var names []types.NamespacedName
+	for _, obj := range relatedObjects {
+		if obj.Group == appsv1.GroupName && obj.Resource == "deployments" {
+			names = append(names, types.NamespacedName{Namespace: obj.Namespace, Name: obj.Name})
+		}
+	}
+	return names
+}
+
+// deploymentNamespaces returns the namespaces of the Deployments
+// present in the given related objects
+func deploymentNamespaces(relatedObjects []configv1.ObjectReference) []string {
+	namespaces := sets.New[string]()
+	for _, name := range deploymentNames(relatedObjects) {
+		namespaces.Insert(name.Namespace)
+	}
+	return sets.List(namespaces)
+}
+
+// pdbNamespaces returns the namespaces of the PodDisruptionBudgets
+// present in the given related objects
+func pdbNamespaces(relatedObjects []configv1.ObjectReference) sets.Set[string] {
+	namespaces := sets.New[string]()
+	for _, obj := range relatedObjects {
+		if obj.Group == policyv1.GroupName && obj.Resource == "poddisruptionbudgets" {
+			namespaces.Insert(obj.Namespace)
+		}
+	}
+	return namespaces
+}
+
 // newOLMObjectReference creates a configv1.ObjectReference for
 // the cluster scoped OLM resources
 func newOLMObjectReference() configv1.ObjectReference {
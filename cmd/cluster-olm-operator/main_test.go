@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInformerNamespaces(t *testing.T) {
+	relatedObjects := []configv1.ObjectReference{
+		{Resource: "deployments", Namespace: "openshift-catalogd", Name: "catalogd"},
+		{Resource: "deployments", Namespace: "openshift-operator-controller", Name: "operator-controller"},
+		{Resource: "serviceaccounts", Namespace: "openshift-catalogd", Name: "catalogd"},
+	}
+	additional := []string{"openshift-extra", "openshift-catalogd"}
+
+	namespaces := informerNamespaces(relatedObjects, additional)
+	expected := []string{"openshift-catalogd", "openshift-extra", "openshift-operator-controller"}
+	if !reflect.DeepEqual(expected, namespaces) {
+		t.Fatalf("expected namespaces %v, got %v", expected, namespaces)
+	}
+
+	informers := v1helpers.NewKubeInformersForNamespaces(kubefake.NewSimpleClientset(), namespaces...)
+	if !informers.Namespaces().Has("openshift-extra") {
+		t.Errorf("expected informer factory to include additional namespace %q, got %v", "openshift-extra", sets.List(informers.Namespaces()))
+	}
+}
+
+type namedController struct {
+	factory.Controller
+	name string
+}
+
+func (c namedController) Name() string {
+	return c.name
+}
+
+func controllerNames(controllers []scheduledController) []string {
+	var names []string
+	for _, c := range controllers {
+		names = append(names, fmt.Sprintf("%s/%d", c.controller.Name(), c.workers))
+	}
+	return names
+}
+
+func TestControllerSetToStart(t *testing.T) {
+	controllers := controllerSet{
+		reporting: []factory.Controller{namedController{name: "incompatible"}, namedController{name: "status"}, namedController{name: "proxy"}},
+		managing:  []factory.Controller{namedController{name: "static"}, namedController{name: "upgradeable"}},
+		dependent: []factory.Controller{namedController{name: "deployment"}, namedController{name: "clustercatalog"}},
+	}
+
+	workers := controllerWorkers{reporting: 4, managing: 2, dependent: 1}
+	for _, tc := range []struct {
+		name              string
+		observeOnly       bool
+		expectedImmediate []string
+		expectedDelayed   []string
+	}{
+		{
+			name:              "default",
+			expectedImmediate: []string{"static/2", "upgradeable/2", "incompatible/4", "status/4", "proxy/4"},
+			expectedDelayed:   []string{"deployment/1", "clustercatalog/1"},
+		},
+		{
+			name:              "observe-only",
+			observeOnly:       true,
+			expectedImmediate: []string{"incompatible/4", "status/4", "proxy/4"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			immediate, delayed := controllers.toStart(tc.observeOnly, workers)
+			if actual := controllerNames(immediate); !reflect.DeepEqual(tc.expectedImmediate, actual) {
+				t.Errorf("expected immediate controllers %v, got %v", tc.expectedImmediate, actual)
+			}
+			if actual := controllerNames(delayed); !reflect.DeepEqual(tc.expectedDelayed, actual) {
+				t.Errorf("expected delayed controllers %v, got %v", tc.expectedDelayed, actual)
+			}
+		})
+	}
+}
+
+func TestOperatorControllersToStart(t *testing.T) {
+	named := func(name string) factory.Controller { return namedController{name: name} }
+	controllers := operatorControllers{
+		staticResources:           []factory.Controller{named("CatalogdStaticResources"), named("OperatorControllerStaticResources")},
+		deployments:               []factory.Controller{named("CatalogdDeployment")},
+		clusterCatalogs:           []factory.Controller{named("CatalogdClusterCatalog")},
+		upgradeableCondition:      named("OLMStaticUpgradeableConditionController"),
+		operatorLogging:           named("ClusterOLMOperatorLoggingController"),
+		operandLeaseCleanup:       named("OLMOperandLeaseCleanupController"),
+		proxyTrustedCA:            named("OLMProxyTrustedCAController"),
+		resourcePermissions:       named("OLMResourcePermissionsController"),
+		incompatibleOperator:      named("OLMIncompatibleOperatorController"),
+		clusterOperator:           named("olm"),
+		proxy:                     named("OLMProxyController"),
+		renderWarnings:            named("OLMRenderWarningsController"),
+		effectiveConfig:           named("OLMEffectiveConfigController"),
+		clusterCatalogStatus:      named("OLMClusterCatalogStatusController"),
+		clusterCatalogImagePolicy: named("OLMClusterCatalogImagePolicyController"),
+		crdEstablished:            named("OLMCRDEstablishedController"),
+		operandDowngrade:          named("OLMOperandDowngradeController"),
+		provenance:                named("OLMClusterOperatorProvenanceController"),
+		// the optional operand PodDisruptionBudget and image controllers are disabled
+	}.set()
+
+	reporting := []string{
+		"OLMIncompatibleOperatorController/1",
+		"olm/1",
+		"OLMProxyController/1",
+		"OLMRenderWarningsController/1",
+		"OLMEffectiveConfigController/1",
+		"OLMClusterCatalogStatusController/1",
+		"OLMClusterCatalogImagePolicyController/1",
+		"OLMCRDEstablishedController/1",
+		"OLMOperandDowngradeController/1",
+		"OLMClusterOperatorProvenanceController/1",
+	}
+	workers := controllerWorkers{reporting: 1, managing: 1, dependent: 1}
+	for _, tc := range []struct {
+		name              string
+		observeOnly       bool
+		expectedImmediate []string
+		expectedDelayed   []string
+	}{
+		{
+			name: "default",
+			expectedImmediate: append([]string{
+				"CatalogdStaticResources/1",
+				"OperatorControllerStaticResources/1",
+				"OLMStaticUpgradeableConditionController/1",
+				"ClusterOLMOperatorLoggingController/1",
+				"OLMOperandLeaseCleanupController/1",
+				"OLMProxyTrustedCAController/1",
+				"OLMResourcePermissionsController/1",
+			}, reporting...),
+			expectedDelayed: []string{"CatalogdDeployment/1", "CatalogdClusterCatalog/1"},
+		},
+		{
+			name:              "observe-only",
+			observeOnly:       true,
+			expectedImmediate: reporting,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			immediate, delayed := controllers.toStart(tc.observeOnly, workers)
+			if actual := controllerNames(immediate); !reflect.DeepEqual(tc.expectedImmediate, actual) {
+				t.Errorf("expected immediate controllers\n%v\ngot\n%v", tc.expectedImmediate, actual)
+			}
+			if actual := controllerNames(delayed); !reflect.DeepEqual(tc.expectedDelayed, actual) {
+				t.Errorf("expected delayed controllers %v, got %v", tc.expectedDelayed, actual)
+			}
+		})
+	}
+}
+
+// runRecordingController records the number of workers it is run with.
+type runRecordingController struct {
+	factory.Controller
+	workers chan int
+}
+
+func (c runRecordingController) Run(_ context.Context, workers int) {
+	c.workers <- workers
+}
+
+func TestStartControllers(t *testing.T) {
+	c := runRecordingController{workers: make(chan int, 1)}
+	startControllers(context.Background(), []scheduledController{{controller: c, workers: 3}})
+
+	select {
+	case workers := <-c.workers:
+		if workers != 3 {
+			t.Errorf("expected the controller to run with 3 workers, got %d", workers)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the controller was not started")
+	}
+}
+
+func TestControllerWorkersValidate(t *testing.T) {
+	if err := (controllerWorkers{reporting: 1, managing: 1, dependent: 1}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := controllerWorkers{reporting: 1, managing: 0, dependent: -1}.validate()
+	for _, expected := range []string{"managing controller workers 0", "dependent controller workers -1"} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/utils/clock"
+)
+
+// startupTimer attributes the operator startup latency to its phases, each measured
+// from the end of the previous one.
+type startupTimer struct {
+	logger logr.Logger
+	clock  clock.PassiveClock
+	start  time.Time
+	last   time.Time
+	phases []interface{}
+}
+
+func newStartupTimer(logger logr.Logger, clock clock.PassiveClock) *startupTimer {
+	now := clock.Now()
+	return &startupTimer{logger: logger, clock: clock, start: now, last: now}
+}
+
+// phaseDone logs the duration of the phase ending now.
+func (t *startupTimer) phaseDone(phase string) {
+	now := t.clock.Now()
+	duration := now.Sub(t.last)
+	t.last = now
+	t.phases = append(t.phases, phase, duration.String())
+	t.logger.Info("startup phase finished", "phase", phase, "duration", duration.String())
+}
+
+// summary logs the total startup duration along with that of every phase.
+func (t *startupTimer) summary() {
+	t.logger.Info("startup finished", append([]interface{}{"total", t.clock.Since(t.start).String()}, t.phases...)...)
+}
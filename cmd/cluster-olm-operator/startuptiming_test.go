@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestStartupTimer(t *testing.T) {
+	var entries []string
+	logger := funcr.New(func(prefix, args string) {
+		entries = append(entries, args)
+	}, funcr.Options{})
+	clock := clocktesting.NewFakePassiveClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	timer := newStartupTimer(logger, clock)
+	clock.SetTime(clock.Now().Add(200 * time.Millisecond))
+	timer.phaseDone("clients")
+	clock.SetTime(clock.Now().Add(1500 * time.Millisecond))
+	timer.phaseDone("build")
+	timer.summary()
+
+	expected := []string{
+		`"level"=0 "msg"="startup phase finished" "phase"="clients" "duration"="200ms"`,
+		`"level"=0 "msg"="startup phase finished" "phase"="build" "duration"="1.5s"`,
+		`"level"=0 "msg"="startup finished" "total"="1.7s" "clients"="200ms" "build"="1.5s"`,
+	}
+	if !reflect.DeepEqual(expected, entries) {
+		t.Errorf("expected log entries\n%v\ngot\n%v", expected, entries)
+	}
+}
@@ -1,9 +1,8 @@
 package utils
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
 
 	semver "github.com/blang/semver/v4"
 )
@@ -18,36 +17,55 @@ func GetNextOCPMinorVersion(versionString string) (*semver.Version, error) {
 	return &v, v.IncrementMinor() // Sets Y=Y+1 and Z=0
 }
 
-func ToAllowedSemver(data []byte) (*semver.Version, error) {
-	var raw interface{}
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, err
+// ResolveOCPVersion returns the OpenShift version the operator runs on, from either
+// the operator image version or the desired version of the ClusterVersion. The
+// operator image version takes precedence unless preferClusterVersion is set; the
+// other source is only looked up when the preferred one is empty or is not a valid
+// semantic version.
+func ResolveOCPVersion(operatorImageVersion string, clusterVersion func() (string, error), preferClusterVersion bool) (string, error) {
+	sources := []struct {
+		name    string
+		version func() (string, error)
+	}{
+		{name: "operator image version", version: func() (string, error) { return operatorImageVersion, nil }},
+		{name: "ClusterVersion desired version", version: clusterVersion},
 	}
-
-	var versionStr string
-
-	switch v := raw.(type) {
-	case float64:
-		versionStr = fmt.Sprintf("%d.%d", int(v), int((v-float64(int(v)))*100)+1)
-	case string:
-		versionStr = v
-	default:
-		return nil, fmt.Errorf("invalid type %T for olm.maxOpenshiftVersion: %s", v, string(data))
+	if preferClusterVersion {
+		sources[0], sources[1] = sources[1], sources[0]
 	}
 
-	if !strings.Contains(versionStr, ".") || strings.Count(versionStr, ".") != 1 {
-		return nil, fmt.Errorf("invalid version format")
+	var errs []error
+	for _, source := range sources {
+		v, err := source.version()
+		if err == nil && v == "" {
+			err = errors.New("not set")
+		}
+		if err == nil {
+			_, err = semver.Parse(v)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.name, err))
+			continue
+		}
+		return v, nil
 	}
+	return "", fmt.Errorf("unable to determine the OpenShift version: %w", errors.Join(errs...))
+}
 
-	if strings.HasPrefix(versionStr, "v") || strings.Count(versionStr, ".") != 1 {
-		return nil, fmt.Errorf("invalid version format")
+// GetNextOCPMinorVersions returns the n minor versions following versionString, in
+// increasing order. Minor versions are never rolled over into the next major, so
+// the versions following 4.9 are 4.10, 4.11 and so on.
+func GetNextOCPMinorVersions(versionString string, n int) ([]semver.Version, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of minor versions %d: must be at least 1", n)
 	}
-
-	// So it accepts only Major.Minor without Patch
-	version, err := semver.ParseTolerant(versionStr)
+	next, err := GetNextOCPMinorVersion(versionString)
 	if err != nil {
 		return nil, err
 	}
-
-	return &version, nil
+	versions := make([]semver.Version, 0, n)
+	for v := *next; len(versions) < n; v.Minor++ {
+		versions = append(versions, v)
+	}
+	return versions, nil
 }
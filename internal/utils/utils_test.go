@@ -1,61 +1,142 @@
 package utils
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/blang/semver/v4"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestParseSemver(t *testing.T) {
+func TestGetNextOCPMinorVersions(t *testing.T) {
 	tests := []struct {
-		name      string
-		jsonInput string
-		want      *semver.Version
-		wantErr   bool
+		name    string
+		version string
+		n       int
+		want    []semver.Version
+		wantErr bool
 	}{
 		{
-			name:      "valid float version",
-			jsonInput: `4.18`,
-			want:      &semver.Version{Major: 4, Minor: 18, Patch: 0},
-			wantErr:   false,
+			name:    "next minor",
+			version: "4.18.3",
+			n:       1,
+			want:    []semver.Version{{Major: 4, Minor: 19}},
 		},
 		{
-			name:      "valid string version",
-			jsonInput: `"4.18"`,
-			want:      &semver.Version{Major: 4, Minor: 18, Patch: 0},
-			wantErr:   false,
+			name:    "next two minors",
+			version: "4.18.3",
+			n:       2,
+			want:    []semver.Version{{Major: 4, Minor: 19}, {Major: 4, Minor: 20}},
 		},
 		{
-			name:      "invalid float version with patch",
-			jsonInput: `4.18.0`,
-			wantErr:   true,
+			name:    "minor rolls past 9 without changing major",
+			version: "4.9.0",
+			n:       2,
+			want:    []semver.Version{{Major: 4, Minor: 10}, {Major: 4, Minor: 11}},
 		},
 		{
-			name:      "invalid string version with patch",
-			jsonInput: `"4.18.0"`,
-			wantErr:   true,
+			name:    "pre-release and build are dropped",
+			version: "4.18.0-0.nightly-2024-10-01-000000+build.1",
+			n:       1,
+			want:    []semver.Version{{Major: 4, Minor: 19}},
 		},
 		{
-			name:      "invalid string with v prefix",
-			jsonInput: `"v4.18"`,
-			wantErr:   true,
+			name:    "zero versions",
+			version: "4.18.0",
+			n:       0,
+			wantErr: true,
 		},
 		{
-			name:      "invalid string with v prefix and patch",
-			jsonInput: `"v4.18.0"`,
-			wantErr:   true,
+			name:    "negative versions",
+			version: "4.18.0",
+			n:       -1,
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			version: "4.18",
+			n:       1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetNextOCPMinorVersions(tt.version, tt.n)
+			if tt.wantErr {
+				assert.Error(t, err, "expected an error but got none")
+			} else {
+				assert.NoError(t, err, "expected no error but got one")
+				assert.Equal(t, tt.want, got, "unexpected minor versions")
+			}
+		})
+	}
+}
+
+func TestResolveOCPVersion(t *testing.T) {
+	clusterVersion := func(v string, err error) func() (string, error) {
+		return func() (string, error) { return v, err }
+	}
+	tests := []struct {
+		name                 string
+		operatorImageVersion string
+		clusterVersion       func() (string, error)
+		preferClusterVersion bool
+		want                 string
+		wantErr              bool
+	}{
+		{
+			name:                 "operator image version",
+			operatorImageVersion: "4.18.3",
+			clusterVersion:       clusterVersion("4.18.4", nil),
+			want:                 "4.18.3",
+		},
+		{
+			name:                 "invalid operator image version falls back to the ClusterVersion",
+			operatorImageVersion: "latest",
+			clusterVersion:       clusterVersion("4.18.4", nil),
+			want:                 "4.18.4",
+		},
+		{
+			name:           "missing operator image version falls back to the ClusterVersion",
+			clusterVersion: clusterVersion("4.18.4", nil),
+			want:           "4.18.4",
+		},
+		{
+			name:                 "ClusterVersion preferred",
+			operatorImageVersion: "4.18.3",
+			clusterVersion:       clusterVersion("4.18.4", nil),
+			preferClusterVersion: true,
+			want:                 "4.18.4",
+		},
+		{
+			name:                 "unavailable preferred ClusterVersion falls back to the operator image version",
+			operatorImageVersion: "4.18.3",
+			clusterVersion:       clusterVersion("", errors.New("not found")),
+			preferClusterVersion: true,
+			want:                 "4.18.3",
+		},
+		{
+			name:           "both missing",
+			clusterVersion: clusterVersion("", nil),
+			wantErr:        true,
+		},
+		{
+			name:                 "both invalid",
+			operatorImageVersion: "4.18",
+			clusterVersion:       clusterVersion("", errors.New("not found")),
+			wantErr:              true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ToAllowedSemver([]byte(tt.jsonInput))
+			got, err := ResolveOCPVersion(tt.operatorImageVersion, tt.clusterVersion, tt.preferClusterVersion)
 			if tt.wantErr {
 				assert.Error(t, err, "expected an error but got none")
 			} else {
 				assert.NoError(t, err, "expected no error but got one")
-				assert.Equal(t, tt.want, got, "unexpected semver version")
+				assert.Equal(t, tt.want, got, "unexpected version")
 			}
 		})
 	}
@@ -3,9 +3,12 @@ package clients
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -34,19 +37,26 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
 	catalogdv1 "github.com/operator-framework/catalogd/api/v1"
 )
 
-const defaultResyncPeriod = 10 * time.Minute
+const (
+	// DefaultResyncPeriod is the resync period of the informers created by New.
+	DefaultResyncPeriod = 10 * time.Minute
+
+	getObjectMetaTimeout = 30 * time.Second
+)
 
 type Clients struct {
 	KubeClient                 kubernetes.Interface
@@ -62,6 +72,11 @@ type Clients struct {
 	KubeInformerFactory        informers.SharedInformerFactory
 	ConfigInformerFactory      configinformer.SharedInformerFactory
 	KubeInformersForNamespaces v1helpers.KubeInformersForNamespaces
+	// OperandKubeInformersForNamespaces holds the informers of the namespaces the
+	// operand Deployments run in, which the Deployment controllers watch.
+	OperandKubeInformersForNamespaces v1helpers.KubeInformersForNamespaces
+
+	dynamicInformers map[string]dynamicinformer.DynamicSharedInformerFactory
 }
 
 func New(cc *controllercmd.ControllerContext) (*Clients, error) {
@@ -84,7 +99,7 @@ func New(cc *controllercmd.ControllerContext) (*Clients, error) {
 	if err != nil {
 		return nil, err
 	}
-	rm, err := apiutil.NewDynamicRESTMapper(cc.KubeConfig, httpClient)
+	rm, err := newRESTMapperWithRetry(apiutil.NewDynamicRESTMapper, cc.KubeConfig, httpClient, restMapperBackoff)
 	if err != nil {
 		return nil, err
 	}
@@ -94,12 +109,14 @@ func New(cc *controllercmd.ControllerContext) (*Clients, error) {
 		return nil, err
 	}
 
-	operatorInformersFactory := operatorinformers.NewSharedInformerFactory(operatorClientset, defaultResyncPeriod)
+	operatorInformersFactory := operatorinformers.NewSharedInformerFactory(operatorClientset, DefaultResyncPeriod)
 
 	opClient := &OperatorClient{
 		clientset: operatorClientset,
 		informers: operatorInformersFactory,
 		clock:     clock.RealClock{},
+
+		statusWriter: newStatusWriter(),
 	}
 
 	configClient, err := configclient.NewForConfig(cc.KubeConfig)
@@ -107,7 +124,7 @@ func New(cc *controllercmd.ControllerContext) (*Clients, error) {
 		return nil, err
 	}
 
-	configInformerFactory := configinformer.NewSharedInformerFactory(configClient, defaultResyncPeriod)
+	configInformerFactory := configinformer.NewSharedInformerFactory(configClient, DefaultResyncPeriod)
 
 	return &Clients{
 		KubeClient:             kubeClient,
@@ -120,11 +137,38 @@ func New(cc *controllercmd.ControllerContext) (*Clients, error) {
 		ClusterCatalogClient:   NewClusterCatalogClient(dynClient),
 		ProxyClient:            NewProxyClient(configInformerFactory),
 		ConfigClient:           configClient,
-		KubeInformerFactory:    informers.NewSharedInformerFactory(kubeClient, defaultResyncPeriod),
+		KubeInformerFactory:    informers.NewSharedInformerFactory(kubeClient, DefaultResyncPeriod),
 		ConfigInformerFactory:  configInformerFactory,
 	}, nil
 }
 
+// restMapperBackoff bounds how long we wait for API discovery to become available
+// at startup, which may race with the apiserver coming up.
+var restMapperBackoff = wait.Backoff{
+	Duration: 1 * time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    6,
+}
+
+type restMapperFunc func(*rest.Config, *http.Client) (meta.RESTMapper, error)
+
+// newRESTMapperWithRetry retries RESTMapper construction with the given backoff,
+// returning the last construction error if it never succeeds.
+func newRESTMapperWithRetry(newRESTMapper restMapperFunc, cfg *rest.Config, httpClient *http.Client, backoff wait.Backoff) (meta.RESTMapper, error) {
+	var (
+		rm      meta.RESTMapper
+		lastErr error
+	)
+	if err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		rm, lastErr = newRESTMapper(cfg, httpClient)
+		return lastErr == nil, nil
+	}); err != nil {
+		return nil, fmt.Errorf("unable to create RESTMapper, API discovery is unavailable after %d attempts: %w", backoff.Steps, lastErr)
+	}
+	return rm, nil
+}
+
 func (c *Clients) StartInformers(ctx context.Context) {
 	c.KubeInformerFactory.Start(ctx.Done())
 	c.ConfigInformerFactory.Start(ctx.Done())
@@ -135,6 +179,27 @@ func (c *Clients) StartInformers(ctx context.Context) {
 	if c.KubeInformersForNamespaces != nil {
 		c.KubeInformersForNamespaces.Start(ctx.Done())
 	}
+	if c.OperandKubeInformersForNamespaces != nil {
+		c.OperandKubeInformersForNamespaces.Start(ctx.Done())
+	}
+	for _, f := range c.dynamicInformers {
+		f.Start(ctx.Done())
+	}
+}
+
+// DynamicInformersForNamespace returns the dynamic informer factory watching the
+// namespace, or cluster-scoped resources when it is empty, creating it on first use.
+// The factories are started along with the other informers.
+func (c *Clients) DynamicInformersForNamespace(namespace string) dynamicinformer.DynamicSharedInformerFactory {
+	if c.dynamicInformers == nil {
+		c.dynamicInformers = map[string]dynamicinformer.DynamicSharedInformerFactory{}
+	}
+	f, ok := c.dynamicInformers[namespace]
+	if !ok {
+		f = dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.DynamicClient, DefaultResyncPeriod, namespace, nil)
+		c.dynamicInformers[namespace] = f
+	}
+	return f
 }
 
 func (c *Clients) ClientHolder() *resourceapply.ClientHolder {
@@ -165,7 +230,7 @@ func (ce ClusterExtensionClient) Informer() informers.GenericInformer {
 }
 
 func NewClusterExtensionClient(dynClient dynamic.Interface) *ClusterExtensionClient {
-	infFact := dynamicinformer.NewDynamicSharedInformerFactory(dynClient, defaultResyncPeriod)
+	infFact := dynamicinformer.NewDynamicSharedInformerFactory(dynClient, DefaultResyncPeriod)
 	clusterExtensionGVR := ocv1.GroupVersion.WithResource("clusterextensions")
 	inf := infFact.ForResource(clusterExtensionGVR)
 
@@ -189,7 +254,7 @@ func (cc *ClusterCatalogClient) Get(key types.NamespacedName) (runtime.Object, e
 }
 
 func NewClusterCatalogClient(dynClient dynamic.Interface) *ClusterCatalogClient {
-	infFact := dynamicinformer.NewDynamicSharedInformerFactory(dynClient, defaultResyncPeriod)
+	infFact := dynamicinformer.NewDynamicSharedInformerFactory(dynClient, DefaultResyncPeriod)
 	clusterCatalogGVR := catalogdv1.GroupVersion.WithResource("clustercatalogs")
 	inf := infFact.ForResource(clusterCatalogGVR)
 
@@ -231,6 +296,63 @@ type OperatorClient struct {
 	clientset operatorclient.Interface
 	informers operatorinformers.SharedInformerFactory
 	clock     clock.PassiveClock
+
+	// statusWriter is shared by the copies of the OperatorClient; when nil, status
+	// applies are neither serialized nor remembered.
+	statusWriter *statusWriter
+}
+
+// statusWriter serializes the status applies of all the controllers sharing the
+// OperatorClient, and remembers the status each field manager last applied until the
+// informer cache observes a newer object. Rapid applies then compute the condition
+// transition times, and whether there is anything to apply, from what was actually
+// applied rather than from a stale cache.
+type statusWriter struct {
+	mu      sync.Mutex
+	applied map[string]appliedStatus
+}
+
+type appliedStatus struct {
+	// cachedResourceVersion is the resourceVersion of the cached object the status
+	// was applied over, empty when the object was not cached.
+	cachedResourceVersion string
+	status                *operatorv1apply.OLMStatusApplyConfiguration
+}
+
+func newStatusWriter() *statusWriter {
+	return &statusWriter{applied: map[string]appliedStatus{}}
+}
+
+func (w *statusWriter) lock() func() {
+	if w == nil {
+		return func() {}
+	}
+	w.mu.Lock()
+	return w.mu.Unlock
+}
+
+// lastApplied returns the status last applied by the field manager, as long as the
+// cache has not changed since, i.e. has not observed that apply yet.
+func (w *statusWriter) lastApplied(fieldManager, cachedResourceVersion string) (*operatorv1apply.OLMStatusApplyConfiguration, bool) {
+	if w == nil {
+		return nil, false
+	}
+	applied, ok := w.applied[fieldManager]
+	if !ok {
+		return nil, false
+	}
+	if applied.cachedResourceVersion != cachedResourceVersion {
+		delete(w.applied, fieldManager)
+		return nil, false
+	}
+	return applied.status, true
+}
+
+func (w *statusWriter) record(fieldManager, cachedResourceVersion string, status *operatorv1apply.OLMStatusApplyConfiguration) {
+	if w == nil {
+		return
+	}
+	w.applied[fieldManager] = appliedStatus{cachedResourceVersion: cachedResourceVersion, status: status}
 }
 
 func (o OperatorClient) Informer() cache.SharedIndexInformer {
@@ -276,49 +398,57 @@ func (o OperatorClient) ApplyOperatorSpec(ctx context.Context, fieldManager stri
 }
 
 func (o OperatorClient) ApplyOperatorStatus(ctx context.Context, fieldManager string, desiredStatus *operatorv1apply.OperatorStatusApplyConfiguration) error {
-	if desiredStatus == nil {
-		panic("desiredStatus is nil")
+	if err := validateDesiredStatus(desiredStatus); err != nil {
+		// panicking under test so we can quickly find it and fix the source,
+		// but never crash the operator over a single malformed condition
+		if strictStatusValidation {
+			panic(err.Error())
+		}
+		klog.FromContext(ctx).Error(err, "refusing to apply invalid operator status", "fieldManager", fieldManager)
+		return err
 	}
 	desiredOLMStatus := &operatorv1apply.OLMStatusApplyConfiguration{
 		OperatorStatusApplyConfiguration: *desiredStatus,
 	}
 
-	for i, curr := range desiredOLMStatus.Conditions {
-		// panicking so we can quickly find it and fix the source
-		if len(ptr.Deref(curr.Type, "")) == 0 {
-			panic(fmt.Sprintf(".status.conditions[%d].type is missing", i))
-		}
-		if len(ptr.Deref(curr.Status, "")) == 0 {
-			panic(fmt.Sprintf(".status.conditions[%q].status is missing", *curr.Type))
-		}
-	}
+	defer o.statusWriter.lock()()
 
+	var cachedResourceVersion string
 	instance, err := o.informers.Operator().V1().OLMs().Lister().Get(globalConfigName)
 	switch {
 	case apierrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("unable to get operator configuration: %w", err)
+	default:
+		cachedResourceVersion = instance.ResourceVersion
+	}
+	previouslyDesiredOLMStatus, applied := o.statusWriter.lastApplied(fieldManager, cachedResourceVersion)
+
+	switch {
+	case !applied && instance == nil:
 		// set last transitionTimes and then apply
 		// If our cache improperly 404's (the lister wasn't synchronized), then we will improperly reset all the last transition times.
 		// This isn't ideal, but we shouldn't hit this case unless a loop isn't waiting for HasSynced.
 		v1helpers.SetApplyConditionsLastTransitionTime(o.clock, &desiredOLMStatus.Conditions, nil)
-	case err != nil:
-		return fmt.Errorf("unable to get operator configuration: %w", err)
 	default:
-		previouslyDesiredOLMStatus, err := extractOLMStatus(instance, fieldManager)
-		if err != nil {
-			return err
+		if !applied {
+			previouslyDesiredOLMStatus, err = extractOLMStatus(instance, fieldManager)
+			if err != nil {
+				return err
+			}
 		}
 
 		// set last transitionTimes to properly calculate a difference
-		// It is possible for last transition time to shift a couple times until the cache updates to have the condition[*].status match,
-		// but it will eventually settle.  The failing sequence looks like
+		// Without the status last applied through the statusWriter, last transition time could shift a couple times until the cache
+		// updates to have the condition[*].status match. The failing sequence looks like
 		/*
 			1. type=foo, status=false, time=t0.Now
 			2. type=foo, status=true, time=t1.Now
 			3. rapid update happens and the cache still indicates #1
 			4. type=foo, status=true, time=t2.Now (this *should* be t1.Now)
 		*/
-		// Eventually the cache updates to see at #2 and we stop applying new times.
-		// This only becomes pathological if the condition is also flapping, but if that happens the time should also update.
+		// #3 now compares against #2 as remembered by the statusWriter. The time can still shift when the cache observes
+		// another write before #2, but it eventually settles once the cache sees #2.
 		switch {
 		case desiredOLMStatus.Conditions != nil && previouslyDesiredOLMStatus != nil:
 			v1helpers.SetApplyConditionsLastTransitionTime(o.clock, &desiredStatus.Conditions, previouslyDesiredOLMStatus.Conditions)
@@ -352,10 +482,30 @@ func (o OperatorClient) ApplyOperatorStatus(ctx context.Context, fieldManager st
 	if err != nil {
 		return fmt.Errorf("unable to ApplyStatus for operator using fieldManager %q: %w", fieldManager, err)
 	}
+	o.statusWriter.record(fieldManager, cachedResourceVersion, desiredOLMStatus)
 
 	return nil
 }
 
+// strictStatusValidation makes ApplyOperatorStatus panic on an invalid status
+// instead of returning an error. It is only meant to be enabled by tests.
+var strictStatusValidation = false
+
+func validateDesiredStatus(desiredStatus *operatorv1apply.OperatorStatusApplyConfiguration) error {
+	if desiredStatus == nil {
+		return errors.New("desiredStatus is nil")
+	}
+	for i, curr := range desiredStatus.Conditions {
+		if len(ptr.Deref(curr.Type, "")) == 0 {
+			return fmt.Errorf(".status.conditions[%d].type is missing", i)
+		}
+		if len(ptr.Deref(curr.Status, "")) == 0 {
+			return fmt.Errorf(".status.conditions[%q].status is missing", *curr.Type)
+		}
+	}
+	return nil
+}
+
 func extractOLMStatus(instance *operatorv1.OLM, fieldManager string) (*operatorv1apply.OLMStatusApplyConfiguration, error) {
 	applyInstance, err := operatorv1apply.ExtractOLMStatus(instance, fieldManager)
 	if err != nil {
@@ -399,8 +549,19 @@ func (o OperatorClient) PatchOperatorStatus(ctx context.Context, jsonPatch *json
 	return nil
 }
 
+// GetObjectMeta satisfies v1helpers.OperatorClient, whose signature does not take a
+// context, so the live read is bounded by getObjectMetaTimeout to avoid blocking
+// callers indefinitely when the apiserver is unresponsive.
 func (o OperatorClient) GetObjectMeta() (*metav1.ObjectMeta, error) {
-	olm, err := o.clientset.OperatorV1().OLMs().Get(context.TODO(), globalConfigName, metav1.GetOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), getObjectMetaTimeout)
+	defer cancel()
+	return o.GetObjectMetaWithContext(ctx)
+}
+
+// GetObjectMetaWithContext returns the live ObjectMeta of the cluster OLM resource,
+// returning as soon as the provided context is cancelled.
+func (o OperatorClient) GetObjectMetaWithContext(ctx context.Context) (*metav1.ObjectMeta, error) {
+	olm, err := o.clientset.OperatorV1().OLMs().Get(ctx, globalConfigName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -472,14 +633,21 @@ func (o OperatorClient) EnsureFinalizer(ctx context.Context, finalizer string) e
 	return nil
 }
 
+// RemoveFinalizer removes the finalizer from the cluster OLM resource. The finalizers
+// are read with a quorum read and the patch is conditioned on the resource version
+// read, so that a stale cache cannot drop the finalizers added by other controllers.
 func (o OperatorClient) RemoveFinalizer(ctx context.Context, finalizer string) error {
-	instance, err := o.informers.Operator().V1().OLMs().Lister().Get(globalConfigName)
+	instance, err := o.clientset.OperatorV1().OLMs().Get(ctx, globalConfigName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
-	newFinalizers := sets.List(sets.New(instance.GetFinalizers()...).Delete(finalizer))
+	finalizers := sets.New(instance.GetFinalizers()...)
+	if !finalizers.Has(finalizer) {
+		return nil
+	}
+	newFinalizers := sets.List(finalizers.Delete(finalizer))
 
-	olm := operatorv1apply.OLM(globalConfigName).WithFinalizers(newFinalizers...)
+	olm := operatorv1apply.OLM(globalConfigName).WithResourceVersion(instance.ResourceVersion).WithFinalizers(newFinalizers...)
 	patch, err := json.Marshal(olm)
 	if err != nil {
 		return err
@@ -491,6 +659,45 @@ func (o OperatorClient) RemoveFinalizer(ctx context.Context, finalizer string) e
 	return nil
 }
 
+// SetAnnotation sets the annotation on the cluster OLM resource, or removes it when
+// value is empty. Nothing is written when the cached resource already matches.
+func (o OperatorClient) SetAnnotation(ctx context.Context, key, value string) error {
+	instance, err := o.informers.Operator().V1().OLMs().Lister().Get(globalConfigName)
+	if err != nil {
+		return err
+	}
+	if current, ok := instance.GetAnnotations()[key]; ok == (value != "") && current == value {
+		return nil
+	}
+
+	// a null value removes the annotation in a merge patch
+	var patchValue any
+	if value != "" {
+		patchValue = value
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{key: patchValue},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := o.clientset.OperatorV1().OLMs().Patch(ctx, globalConfigName, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: fieldManager}); err != nil {
+		return fmt.Errorf("unable to set annotation %q on the OLM resource: %w", key, err)
+	}
+	return nil
+}
+
+// GetAnnotation returns the annotation of the cached cluster OLM resource.
+func (o OperatorClient) GetAnnotation(key string) (string, error) {
+	instance, err := o.informers.Operator().V1().OLMs().Lister().Get(globalConfigName)
+	if err != nil {
+		return "", err
+	}
+	return instance.GetAnnotations()[key], nil
+}
+
 func generateOLMPatch(resourceVersion string, in any, fieldPath ...string) ([]byte, error) {
 	var u unstructured.Unstructured
 	u.SetAPIVersion(schema.GroupVersion{Group: operatorv1.GroupName, Version: "v1"}.String())
@@ -0,0 +1,311 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	operatorv1apply "github.com/openshift/client-go/operator/applyconfigurations/operator/v1"
+	operatorclient "github.com/openshift/client-go/operator/clientset/versioned"
+	operatorfake "github.com/openshift/client-go/operator/clientset/versioned/fake"
+	operatorinformers "github.com/openshift/client-go/operator/informers/externalversions"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestGetObjectMetaWithContextCancelled(t *testing.T) {
+	// The server never responds on its own; requests only complete once the client gives up.
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	clientset, err := operatorclient.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error creating clientset: %v", err)
+	}
+	o := OperatorClient{clientset: clientset}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := o.GetObjectMetaWithContext(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error from a cancelled context but got none")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetObjectMetaWithContext did not return promptly after the context was cancelled")
+	}
+}
+
+func TestNewRESTMapperWithRetry(t *testing.T) {
+	backoff := wait.Backoff{Duration: time.Millisecond, Factor: 1, Steps: 3}
+
+	for _, tc := range []struct {
+		name          string
+		failures      int
+		expectError   bool
+		expectedCalls int
+	}{
+		{
+			name:          "succeeds immediately",
+			failures:      0,
+			expectedCalls: 1,
+		},
+		{
+			name:          "succeeds after retries",
+			failures:      2,
+			expectedCalls: 3,
+		},
+		{
+			name:          "fails after all retries",
+			failures:      5,
+			expectError:   true,
+			expectedCalls: 3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			flaky := func(_ *rest.Config, _ *http.Client) (meta.RESTMapper, error) {
+				calls++
+				if calls <= tc.failures {
+					return nil, errors.New("discovery unavailable")
+				}
+				return meta.NewDefaultRESTMapper(nil), nil
+			}
+
+			rm, err := newRESTMapperWithRetry(flaky, &rest.Config{}, http.DefaultClient, backoff)
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.expectedCalls, calls)
+			}
+			if tc.expectError {
+				if err == nil || !strings.Contains(err.Error(), "API discovery is unavailable") {
+					t.Fatalf("expected discovery unavailable error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rm == nil {
+				t.Fatal("expected a RESTMapper but got nil")
+			}
+		})
+	}
+}
+
+func TestApplyOperatorStatusInvalidConditions(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		desiredStatus *operatorv1apply.OperatorStatusApplyConfiguration
+	}{
+		{
+			name: "nil status",
+		},
+		{
+			name: "empty condition type",
+			desiredStatus: operatorv1apply.OperatorStatus().WithConditions(
+				operatorv1apply.OperatorCondition().WithStatus(operatorv1.ConditionTrue),
+			),
+		},
+		{
+			name: "empty condition status",
+			desiredStatus: operatorv1apply.OperatorStatus().WithConditions(
+				operatorv1apply.OperatorCondition().WithType("TestDegraded"),
+			),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("unexpected panic: %v", r)
+				}
+			}()
+			if err := (OperatorClient{}).ApplyOperatorStatus(context.Background(), "test", tc.desiredStatus); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestApplyOperatorStatusStrictValidation(t *testing.T) {
+	strictStatusValidation = true
+	defer func() { strictStatusValidation = false }()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected a panic in strict mode")
+		}
+	}()
+	_ = (OperatorClient{}).ApplyOperatorStatus(context.Background(), "test", operatorv1apply.OperatorStatus().WithConditions(
+		operatorv1apply.OperatorCondition().WithType("TestDegraded"),
+	))
+}
+
+// newStaleCacheOperatorClient returns an OperatorClient over a fake clientset whose
+// informer cache is never started, so that it does not observe the applied statuses.
+func newStaleCacheOperatorClient(t *testing.T, fakeClock clock.PassiveClock) (*OperatorClient, *operatorfake.Clientset) {
+	t.Helper()
+	olm := &operatorv1.OLM{ObjectMeta: metav1.ObjectMeta{Name: globalConfigName, ResourceVersion: "1"}}
+	clientset := operatorfake.NewClientset(olm)
+	informers := operatorinformers.NewSharedInformerFactory(clientset, 0)
+	if err := informers.Operator().V1().OLMs().Informer().GetIndexer().Add(olm); err != nil {
+		t.Fatal(err)
+	}
+	return &OperatorClient{clientset: clientset, informers: informers, clock: fakeClock, statusWriter: newStatusWriter()}, clientset
+}
+
+func applyCondition(t *testing.T, client *OperatorClient, manager, conditionType string, status operatorv1.ConditionStatus) {
+	t.Helper()
+	if err := client.ApplyOperatorStatus(context.Background(), manager, operatorv1apply.OperatorStatus().WithConditions(
+		operatorv1apply.OperatorCondition().WithType(conditionType).WithStatus(status),
+	)); err != nil {
+		t.Errorf("unexpected error applying %s=%s: %v", conditionType, status, err)
+	}
+}
+
+func liveConditions(t *testing.T, clientset *operatorfake.Clientset) map[string]operatorv1.OperatorCondition {
+	t.Helper()
+	olm, err := clientset.OperatorV1().OLMs().Get(context.Background(), globalConfigName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conditions := map[string]operatorv1.OperatorCondition{}
+	for _, cond := range olm.Status.Conditions {
+		conditions[cond.Type] = cond
+	}
+	return conditions
+}
+
+func TestApplyOperatorStatusStaleCache(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fakeClock := clocktesting.NewFakePassiveClock(start)
+	client, clientset := newStaleCacheOperatorClient(t, fakeClock)
+
+	applyCondition(t, client, "test", "FooDegraded", operatorv1.ConditionFalse)
+	fakeClock.SetTime(start.Add(time.Minute))
+	applyCondition(t, client, "test", "FooDegraded", operatorv1.ConditionTrue)
+
+	// the cache still holds the object without status, the transition time must not move
+	fakeClock.SetTime(start.Add(2 * time.Minute))
+	applyCondition(t, client, "test", "FooDegraded", operatorv1.ConditionTrue)
+	if cond := liveConditions(t, clientset)["FooDegraded"]; cond.Status != operatorv1.ConditionTrue || !cond.LastTransitionTime.Time.Equal(start.Add(time.Minute)) {
+		t.Fatalf("expected FooDegraded=True since %s, got %+v", start.Add(time.Minute), cond)
+	}
+	applies := 0
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "patch" {
+			applies++
+		}
+	}
+	if applies != 2 {
+		t.Errorf("expected the unchanged status not to be applied again, got %d applies", applies)
+	}
+
+	// once the cache observes the applied object, it is used again
+	live, err := clientset.OperatorV1().OLMs().Get(context.Background(), globalConfigName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.informers.Operator().V1().OLMs().Informer().GetIndexer().Update(live); err != nil {
+		t.Fatal(err)
+	}
+	fakeClock.SetTime(start.Add(3 * time.Minute))
+	applyCondition(t, client, "test", "FooDegraded", operatorv1.ConditionTrue)
+	if cond := liveConditions(t, clientset)["FooDegraded"]; !cond.LastTransitionTime.Time.Equal(start.Add(time.Minute)) {
+		t.Fatalf("expected FooDegraded to keep its transition time %s, got %+v", start.Add(time.Minute), cond)
+	}
+}
+
+func TestApplyOperatorStatusConcurrentWriters(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	client, clientset := newStaleCacheOperatorClient(t, clocktesting.NewFakePassiveClock(start))
+
+	const writers, rounds = 5, 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			manager := fmt.Sprintf("controller-%d", i)
+			for round := 0; round < rounds; round++ {
+				applyCondition(t, client, manager, fmt.Sprintf("Controller%dDegraded", i), operatorv1.ConditionFalse)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	conditions := liveConditions(t, clientset)
+	if len(conditions) != writers {
+		t.Fatalf("expected %d conditions, got %+v", writers, conditions)
+	}
+	for name, cond := range conditions {
+		if cond.Status != operatorv1.ConditionFalse || !cond.LastTransitionTime.Time.Equal(start) {
+			t.Errorf("expected %s=False since %s, got %+v", name, start, cond)
+		}
+	}
+	applies := 0
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "patch" {
+			applies++
+		}
+	}
+	if applies != writers {
+		t.Errorf("expected a single apply per writer, got %d applies", applies)
+	}
+}
+
+func TestRemoveFinalizerStaleCache(t *testing.T) {
+	const owned, other = "olm.openshift.io/cleanup", "example.com/other"
+	cached := &operatorv1.OLM{ObjectMeta: metav1.ObjectMeta{Name: globalConfigName, ResourceVersion: "1", Finalizers: []string{owned}}}
+	// another controller added its finalizer after the cache last observed the resource
+	live := cached.DeepCopy()
+	live.ResourceVersion = "2"
+	live.Finalizers = append(live.Finalizers, other)
+
+	clientset := operatorfake.NewClientset(live)
+	informers := operatorinformers.NewSharedInformerFactory(clientset, 0)
+	if err := informers.Operator().V1().OLMs().Informer().GetIndexer().Add(cached); err != nil {
+		t.Fatal(err)
+	}
+	client := &OperatorClient{clientset: clientset, informers: informers, clock: clock.RealClock{}, statusWriter: newStatusWriter()}
+
+	if err := client.RemoveFinalizer(context.Background(), owned); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var patches []string
+	for _, action := range clientset.Actions() {
+		if patch, ok := action.(clienttesting.PatchAction); ok {
+			patches = append(patches, string(patch.GetPatch()))
+		}
+	}
+	if len(patches) != 1 {
+		t.Fatalf("expected a single patch, got %q", patches)
+	}
+	if !strings.Contains(patches[0], other) || strings.Contains(patches[0], owned) {
+		t.Errorf("expected the patch to keep only %q, got %s", other, patches[0])
+	}
+	if !strings.Contains(patches[0], `"resourceVersion":"2"`) {
+		t.Errorf("expected the patch to be conditioned on the live resource version, got %s", patches[0])
+	}
+}
@@ -0,0 +1,153 @@
+// Package compatibility evaluates the properties of installed bundles against the
+// OpenShift version a cluster upgrades to.
+package compatibility
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	semver "github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+// MaxOpenShiftVersionProperty is the bundle property holding the newest OpenShift
+// Major.Minor version the bundle supports.
+const MaxOpenShiftVersionProperty = "olm.maxOpenShiftVersion"
+
+// Check evaluates the properties of an installed bundle against the target OCP
+// minor version. If the bundle is incompatible, a non-empty message describing the
+// incompatibility is returned. Any errors encountered while evaluating the
+// properties are returned, along with the message when the properties that could be
+// evaluated already make the bundle incompatible.
+type Check func(props []property.Property, targetVersion semver.Version) (string, error)
+
+// RunChecks runs every check against the given properties and returns the messages
+// of all failing checks. Errors from individual checks are joined and do not
+// prevent the remaining checks from running, nor the message a check returned with
+// its error from being reported.
+func RunChecks(checks []Check, props []property.Property, targetVersion semver.Version) ([]string, error) {
+	var (
+		reasons []string
+		errs    []error
+	)
+	for _, check := range checks {
+		reason, err := check(props, targetVersion)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons, errors.Join(errs...)
+}
+
+// MaxOpenShiftVersionCheck flags bundles whose olm.maxOpenShiftVersion property is
+// lower than the target OCP minor version. When the property is set more than once,
+// the first one is evaluated and the duplicate is reported as an error.
+func MaxOpenShiftVersionCheck(props []property.Property, targetVersion semver.Version) (string, error) {
+	maxVersion, err := MaxOpenShiftVersion(props)
+	if !IsCompatible(maxVersion, targetVersion) {
+		return fmt.Sprintf("%s=%d.%d", MaxOpenShiftVersionProperty, maxVersion.Major, maxVersion.Minor), err
+	}
+	return "", err
+}
+
+// IsCompatible returns whether a bundle supporting OpenShift up to maxVersion may run
+// on targetVersion. A bundle without a maximum version is compatible with any version.
+func IsCompatible(maxVersion *semver.Version, targetVersion semver.Version) bool {
+	return maxVersion == nil || maxVersion.GTE(targetVersion)
+}
+
+// MaxOpenShiftVersion returns the olm.maxOpenShiftVersion of the bundle, or nil when
+// the property is not set. When it is set more than once, the first one is returned
+// along with an error.
+func MaxOpenShiftVersion(props []property.Property) (*semver.Version, error) {
+	var maxVersion *semver.Version
+	for _, p := range props {
+		if p.Type != MaxOpenShiftVersionProperty {
+			continue
+		}
+		if maxVersion != nil {
+			return maxVersion, fmt.Errorf("more than one %s found in bundle", MaxOpenShiftVersionProperty)
+		}
+		v, err := NormalizeVersion(p.Value)
+		if err != nil {
+			return nil, fmt.Errorf("error converting to semver for version %s: %v", string(p.Value), err)
+		}
+		maxVersion = v
+	}
+	return maxVersion, nil
+}
+
+// ParseProperties parses the JSON list of properties recorded in the olm.properties
+// annotation of a bundle.
+func ParseProperties(raw string) ([]property.Property, error) {
+	var props []property.Property
+	if err := json.Unmarshal([]byte(raw), &props); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal properties annotation: %w", err)
+	}
+	return props, nil
+}
+
+// NormalizeVersion parses a Major.Minor version given as a JSON number or string,
+// such as the value of the olm.maxOpenShiftVersion property. Versions with a patch
+// are rejected.
+func NormalizeVersion(data []byte) (*semver.Version, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var versionStr string
+
+	switch v := raw.(type) {
+	case float64:
+		// the minor is taken from the JSON text: as a float, 4.20 would be 4.2
+		versionStr = strings.TrimSpace(string(data))
+	case string:
+		versionStr = normalizeVersionString(v)
+	default:
+		return nil, fmt.Errorf("invalid type %T for olm.maxOpenshiftVersion: %s", v, string(data))
+	}
+
+	if strings.Count(versionStr, ".") != 1 {
+		return nil, fmt.Errorf("invalid version format %q: expected Major.Minor", versionStr)
+	}
+
+	// So it accepts only Major.Minor without Patch
+	version, err := semver.ParseTolerant(versionStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// normalizeVersionString strips whitespace, quotes embedded in the value
+// (e.g. "\"4.18\"") and a leading "v" from a version string.
+func normalizeVersionString(v string) string {
+	v = strings.TrimSpace(v)
+	v = strings.Trim(v, `"'`)
+	return strings.TrimPrefix(v, "v")
+}
+
+// UpgradeTargetMinorVersion returns the minor version compatibility is evaluated
+// against: the minor of the pending desired update version when it is beyond the
+// next minor, otherwise the next minor itself.
+func UpgradeTargetMinorVersion(nextOCPMinorVersion semver.Version, desiredUpdateVersion string) semver.Version {
+	if desiredUpdateVersion == "" {
+		return nextOCPMinorVersion
+	}
+	desired, err := semver.Parse(desiredUpdateVersion)
+	if err != nil {
+		return nextOCPMinorVersion
+	}
+	target := semver.Version{Major: desired.Major, Minor: desired.Minor}
+	if target.GT(nextOCPMinorVersion) {
+		return target
+	}
+	return nextOCPMinorVersion
+}
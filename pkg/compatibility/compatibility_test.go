@@ -0,0 +1,339 @@
+package compatibility
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	semver "github.com/blang/semver/v4"
+	"github.com/operator-framework/operator-registry/alpha/property"
+)
+
+func maxOpenShiftVersionProp(value string) property.Property {
+	return property.Property{Type: MaxOpenShiftVersionProperty, Value: json.RawMessage(value)}
+}
+
+func TestRunCompatibilityChecks(t *testing.T) {
+	nextOCPMinorVersion := semver.MustParse("4.18.0")
+
+	// requiredPackageCheck is a trivial second check used to validate that
+	// additional checks can be plugged in alongside the max version check.
+	requiredPackageCheck := func(props []property.Property, _ semver.Version) (string, error) {
+		for _, p := range props {
+			if p.Type == property.TypePackageRequired {
+				return "requires another package", nil
+			}
+		}
+		return "", nil
+	}
+	failingCheck := func(_ []property.Property, _ semver.Version) (string, error) {
+		return "", errors.New("boom")
+	}
+
+	for _, tc := range []struct {
+		name            string
+		checks          []Check
+		props           []property.Property
+		expectedReasons []string
+		expectError     bool
+	}{
+		{
+			name:   "no properties, compatible",
+			checks: []Check{MaxOpenShiftVersionCheck, requiredPackageCheck},
+		},
+		{
+			name:   "max version equal to next minor, compatible",
+			checks: []Check{MaxOpenShiftVersionCheck},
+			props:  []property.Property{maxOpenShiftVersionProp(`"4.18"`)},
+		},
+		{
+			name:            "max version lower than next minor, incompatible",
+			checks:          []Check{MaxOpenShiftVersionCheck},
+			props:           []property.Property{maxOpenShiftVersionProp(`"4.17"`)},
+			expectedReasons: []string{"olm.maxOpenShiftVersion=4.17"},
+		},
+		{
+			name:   "both checks fail, all reasons reported",
+			checks: []Check{MaxOpenShiftVersionCheck, requiredPackageCheck},
+			props: []property.Property{
+				maxOpenShiftVersionProp(`"4.17"`),
+				{Type: property.TypePackageRequired, Value: json.RawMessage(`{"packageName":"foo","versionRange":">=1.0.0"}`)},
+			},
+			expectedReasons: []string{"olm.maxOpenShiftVersion=4.17", "requires another package"},
+		},
+		{
+			name:   "only second check fails",
+			checks: []Check{MaxOpenShiftVersionCheck, requiredPackageCheck},
+			props: []property.Property{
+				maxOpenShiftVersionProp(`"4.19"`),
+				{Type: property.TypePackageRequired, Value: json.RawMessage(`{"packageName":"foo","versionRange":">=1.0.0"}`)},
+			},
+			expectedReasons: []string{"requires another package"},
+		},
+		{
+			name:        "more than one max version, first compatible, error",
+			checks:      []Check{MaxOpenShiftVersionCheck},
+			props:       []property.Property{maxOpenShiftVersionProp(`"4.19"`), maxOpenShiftVersionProp(`"4.17"`)},
+			expectError: true,
+		},
+		{
+			name:            "more than one max version, first incompatible, incompatible and error",
+			checks:          []Check{MaxOpenShiftVersionCheck},
+			props:           []property.Property{maxOpenShiftVersionProp(`"4.17"`), maxOpenShiftVersionProp(`"4.19"`)},
+			expectedReasons: []string{"olm.maxOpenShiftVersion=4.17"},
+			expectError:     true,
+		},
+		{
+			name:        "invalid max version, error",
+			checks:      []Check{MaxOpenShiftVersionCheck},
+			props:       []property.Property{maxOpenShiftVersionProp(`"4.17.1"`)},
+			expectError: true,
+		},
+		{
+			name:            "failing check does not prevent other checks",
+			checks:          []Check{failingCheck, MaxOpenShiftVersionCheck},
+			props:           []property.Property{maxOpenShiftVersionProp(`"4.17"`)},
+			expectedReasons: []string{"olm.maxOpenShiftVersion=4.17"},
+			expectError:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reasons, err := RunChecks(tc.checks, tc.props, nextOCPMinorVersion)
+			if tc.expectError != (err != nil) {
+				t.Errorf("expected error: %v, got: %v", tc.expectError, err)
+			}
+			if !reflect.DeepEqual(tc.expectedReasons, reasons) {
+				t.Errorf("expected reasons %v, got %v", tc.expectedReasons, reasons)
+			}
+		})
+	}
+}
+
+func TestUpgradeTargetMinorVersion(t *testing.T) {
+	nextOCPMinorVersion := semver.MustParse("4.18.0")
+	for _, tc := range []struct {
+		name                 string
+		desiredUpdateVersion string
+		expected             semver.Version
+	}{
+		{
+			name:     "no pending update, next minor",
+			expected: nextOCPMinorVersion,
+		},
+		{
+			name:                 "desired update to the next minor",
+			desiredUpdateVersion: "4.18.3",
+			expected:             nextOCPMinorVersion,
+		},
+		{
+			name:                 "desired update two minors ahead",
+			desiredUpdateVersion: "4.19.2",
+			expected:             semver.MustParse("4.19.0"),
+		},
+		{
+			name:                 "desired z-stream update, next minor",
+			desiredUpdateVersion: "4.17.9",
+			expected:             nextOCPMinorVersion,
+		},
+		{
+			name:                 "unparseable desired update, next minor",
+			desiredUpdateVersion: "latest",
+			expected:             nextOCPMinorVersion,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := UpgradeTargetMinorVersion(nextOCPMinorVersion, tc.desiredUpdateVersion)
+			if !actual.Equals(tc.expected) {
+				t.Errorf("expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIsCompatible(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		maxVersion    *semver.Version
+		targetVersion semver.Version
+		expected      bool
+	}{
+		{
+			name:          "no max version",
+			targetVersion: semver.MustParse("4.18.0"),
+			expected:      true,
+		},
+		{
+			name:          "max version equal to the target",
+			maxVersion:    &semver.Version{Major: 4, Minor: 18},
+			targetVersion: semver.MustParse("4.18.0"),
+			expected:      true,
+		},
+		{
+			name:          "max version one minor below the target",
+			maxVersion:    &semver.Version{Major: 4, Minor: 17},
+			targetVersion: semver.MustParse("4.18.0"),
+		},
+		{
+			name:          "minor versions compare numerically",
+			maxVersion:    &semver.Version{Major: 4, Minor: 10},
+			targetVersion: semver.MustParse("4.9.0"),
+			expected:      true,
+		},
+		{
+			name:          "next major",
+			maxVersion:    &semver.Version{Major: 4, Minor: 99},
+			targetVersion: semver.MustParse("5.0.0"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := IsCompatible(tc.maxVersion, tc.targetVersion); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMaxOpenShiftVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		props       []property.Property
+		expected    *semver.Version
+		expectError bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:     "set",
+			props:    []property.Property{{Type: property.TypePackage, Value: json.RawMessage(`{}`)}, maxOpenShiftVersionProp(`"4.17"`)},
+			expected: &semver.Version{Major: 4, Minor: 17},
+		},
+		{
+			name:        "more than one, the first is returned",
+			props:       []property.Property{maxOpenShiftVersionProp(`"4.17"`), maxOpenShiftVersionProp(`"4.19"`)},
+			expected:    &semver.Version{Major: 4, Minor: 17},
+			expectError: true,
+		},
+		{
+			name:        "invalid",
+			props:       []property.Property{maxOpenShiftVersionProp(`"4.17.1"`)},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := MaxOpenShiftVersion(tc.props)
+			if tc.expectError != (err != nil) {
+				t.Errorf("expected error: %v, got: %v", tc.expectError, err)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseProperties(t *testing.T) {
+	props, err := ParseProperties(`[{"type":"olm.maxOpenShiftVersion","value":"4.17"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []property.Property{maxOpenShiftVersionProp(`"4.17"`)}
+	if !reflect.DeepEqual(expected, props) {
+		t.Errorf("expected properties %v, got %v", expected, props)
+	}
+
+	if _, err := ParseProperties(`{"type":"olm.maxOpenShiftVersion"}`); err == nil {
+		t.Error("expected an error for properties that are not a list")
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		jsonInput   string
+		expected    *semver.Version
+		expectError bool
+	}{
+		{
+			name:      "valid float version",
+			jsonInput: `4.18`,
+			expected:  &semver.Version{Major: 4, Minor: 18},
+		},
+		{
+			name:      "valid float version with a single digit minor",
+			jsonInput: `4.9`,
+			expected:  &semver.Version{Major: 4, Minor: 9},
+		},
+		{
+			name:      "valid float version with a trailing zero minor",
+			jsonInput: `4.20`,
+			expected:  &semver.Version{Major: 4, Minor: 20},
+		},
+		{
+			name:      "valid string version",
+			jsonInput: `"4.18"`,
+			expected:  &semver.Version{Major: 4, Minor: 18},
+		},
+		{
+			name:      "valid string with a zero minor",
+			jsonInput: `"5.0"`,
+			expected:  &semver.Version{Major: 5, Minor: 0},
+		},
+		{
+			name:        "invalid float version with patch",
+			jsonInput:   `4.18.0`,
+			expectError: true,
+		},
+		{
+			name:        "invalid string version with patch",
+			jsonInput:   `"4.18.0"`,
+			expectError: true,
+		},
+		{
+			name:      "valid string with v prefix",
+			jsonInput: `"v4.18"`,
+			expected:  &semver.Version{Major: 4, Minor: 18},
+		},
+		{
+			name:      "valid string with embedded quotes",
+			jsonInput: `"\"4.18\""`,
+			expected:  &semver.Version{Major: 4, Minor: 18},
+		},
+		{
+			name:      "valid string with embedded quotes and v prefix",
+			jsonInput: `"\"v4.18\""`,
+			expected:  &semver.Version{Major: 4, Minor: 18},
+		},
+		{
+			name:        "invalid string with v prefix and patch",
+			jsonInput:   `"v4.18.0"`,
+			expectError: true,
+		},
+		{
+			name:        "invalid string",
+			jsonInput:   `"four.eighteen"`,
+			expectError: true,
+		},
+		{
+			name:        "invalid string without minor",
+			jsonInput:   `"v4"`,
+			expectError: true,
+		},
+		{
+			name:        "invalid type",
+			jsonInput:   `true`,
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := NormalizeVersion([]byte(tc.jsonInput))
+			if tc.expectError != (err != nil) {
+				t.Fatalf("expected error: %v, got: %v", tc.expectError, err)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	// maxLoggedPatchBytes bounds the size of the patch body included in apply logs.
+	maxLoggedPatchBytes = 2048
+
+	redactedValue = "REDACTED"
+)
+
+// logApply logs the apply of the manifest at the klog verbosity, skipping it when the
+// verbosity is not positive. The values of Secrets are redacted and the patch body is
+// truncated to maxLoggedPatchBytes.
+func logApply(logger logr.Logger, verbosity int, resource, namespace, name, fieldManager string, manifest []byte) {
+	if verbosity <= 0 {
+		return
+	}
+	logger = logger.V(verbosity)
+	if !logger.Enabled() {
+		return
+	}
+	logger.Info("applying manifest", "resource", resource, "namespace", namespace, "name", name, "fieldManager", fieldManager, "patch", loggablePatch(manifest))
+}
+
+// loggablePatch returns the manifest with its Secret values redacted, truncated to
+// maxLoggedPatchBytes. A manifest that cannot be decoded is not logged, as it can not
+// be told apart from a Secret.
+func loggablePatch(manifest []byte) string {
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal(manifest, &obj.Object); err != nil {
+		return fmt.Sprintf("<undecodable manifest: %v>", err)
+	}
+	if obj.GetAPIVersion() == "v1" && obj.GetKind() == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			values, ok := obj.Object[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for key := range values {
+				values[key] = redactedValue
+			}
+		}
+	}
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return fmt.Sprintf("<unencodable manifest: %v>", err)
+	}
+	if len(data) > maxLoggedPatchBytes {
+		return fmt.Sprintf("%s... (%d bytes truncated)", data[:maxLoggedPatchBytes], len(data)-maxLoggedPatchBytes)
+	}
+	return string(data)
+}
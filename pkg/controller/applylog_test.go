@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+func TestLogApply(t *testing.T) {
+	secret := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: pull-secret
+  namespace: openshift-catalogd
+data:
+  .dockerconfigjson: c2VjcmV0
+stringData:
+  token: hunter2
+`)
+	configMap := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  key: visible
+`)
+
+	for _, tc := range []struct {
+		name              string
+		applyVerbosity    int
+		loggerVerbosity   int
+		manifest          []byte
+		expectLog         bool
+		expectContains    []string
+		expectNotContains []string
+	}{
+		{
+			name:            "disabled",
+			applyVerbosity:  0,
+			loggerVerbosity: 10,
+			manifest:        configMap,
+		},
+		{
+			name:            "below the logger verbosity",
+			applyVerbosity:  6,
+			loggerVerbosity: 4,
+			manifest:        configMap,
+		},
+		{
+			name:            "at the logger verbosity",
+			applyVerbosity:  6,
+			loggerVerbosity: 6,
+			manifest:        configMap,
+			expectLog:       true,
+			expectContains:  []string{`"fieldManager"="test-manager"`, `"name"="config"`, "visible"},
+		},
+		{
+			name:              "secret values are redacted",
+			applyVerbosity:    6,
+			loggerVerbosity:   6,
+			manifest:          secret,
+			expectLog:         true,
+			expectContains:    []string{".dockerconfigjson", redactedValue},
+			expectNotContains: []string{"c2VjcmV0", "hunter2"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs []string
+			logger := funcr.New(func(prefix, args string) {
+				logs = append(logs, prefix+" "+args)
+			}, funcr.Options{Verbosity: tc.loggerVerbosity})
+
+			logApply(logger, tc.applyVerbosity, "/v1, Resource=configmaps", "", "config", "test-manager", tc.manifest)
+
+			if tc.expectLog != (len(logs) > 0) {
+				t.Fatalf("expected log: %v, got logs: %v", tc.expectLog, logs)
+			}
+			output := strings.Join(logs, "\n")
+			for _, expected := range tc.expectContains {
+				if !strings.Contains(output, expected) {
+					t.Errorf("expected logs to contain %q, got: %s", expected, output)
+				}
+			}
+			for _, unexpected := range tc.expectNotContains {
+				if strings.Contains(output, unexpected) {
+					t.Errorf("expected logs not to contain %q, got: %s", unexpected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestLoggablePatchTruncated(t *testing.T) {
+	manifest := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: big\ndata:\n  key: " + strings.Repeat("x", 2*maxLoggedPatchBytes) + "\n")
+	patch := loggablePatch(manifest)
+	if !strings.HasSuffix(patch, "bytes truncated)") {
+		t.Errorf("expected the patch to be truncated, got %d bytes", len(patch))
+	}
+	if len(patch) > maxLoggedPatchBytes+64 {
+		t.Errorf("expected the patch to be at most about %d bytes, got %d", maxLoggedPatchBytes, len(patch))
+	}
+}
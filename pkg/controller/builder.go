@@ -7,28 +7,41 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/staticresourcecontroller"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"github.com/openshift/cluster-olm-operator/pkg/clients"
@@ -42,6 +55,50 @@ type Builder struct {
 	Clients           *clients.Clients
 	ControllerContext *controllercmd.ControllerContext
 	KnownRESTMappings map[schema.GroupVersionKind]*meta.RESTMapping
+
+	// DeleteStaticResourcesOnRemoval makes the static resource controllers delete
+	// the non-CRD resources they manage when the OLM resource is set to Removed.
+	// CRDs are always retained so that user data is not lost.
+	DeleteStaticResourcesOnRemoval bool
+
+	// OperandConfigs holds per-component overrides applied to the operand Deployments.
+	OperandConfigs OperandConfigs
+
+	// ReportOnlyClusterCatalogs names the managed ClusterCatalogs whose drift from
+	// their manifest is only reported through events instead of being reverted.
+	ReportOnlyClusterCatalogs sets.Set[string]
+
+	// OperatorVersion, when set, is recorded on the operand Deployments so that
+	// a later operator downgrade can be detected.
+	OperatorVersion string
+
+	// ClusterCatalogRolloutTimeout, when set, holds the Deployment controllers of the
+	// components in ClusterCatalogGatedComponents until the managed ClusterCatalogs are
+	// serving, for at most this duration.
+	ClusterCatalogRolloutTimeout  time.Duration
+	ClusterCatalogGatedComponents sets.Set[string]
+
+	// NonForcedStaticResourceKinds names the kinds of static resources that are only
+	// created when missing: changes made to existing ones are logged, not reverted.
+	// CustomResourceDefinitions are always enforced.
+	NonForcedStaticResourceKinds sets.Set[string]
+
+	// ExcludedManifests holds glob patterns, in the syntax of path.Match, matched against
+	// the paths of the asset files, e.g. "operator-controller/*-deployment.yaml". Matching
+	// manifests are not managed: no controller is built for them and they are not related
+	// objects.
+	ExcludedManifests []string
+
+	// SlowSyncThreshold is the sync duration above which the ClusterCatalog
+	// controllers log a warning. Zero disables the warning.
+	SlowSyncThreshold time.Duration
+
+	// ApplyLogVerbosity, when positive, is the klog verbosity at which every applied
+	// manifest is logged. Zero disables the apply logs.
+	ApplyLogVerbosity int
+
+	// warnings holds the non-fatal problems found by the last BuildControllers.
+	warnings []string
 }
 
 func (b *Builder) BuildControllers(subDirectories ...string) (map[string]factory.Controller, map[string]factory.Controller, map[string]factory.Controller, []configv1.ObjectReference, error) {
@@ -50,16 +107,35 @@ func (b *Builder) BuildControllers(subDirectories ...string) (map[string]factory
 		deploymentControllers     = map[string]factory.Controller{}
 		clusterCatalogControllers = map[string]factory.Controller{}
 		relatedObjects            []configv1.ObjectReference
+		staticResources           []staticResourceFileSet
+		owners                    = map[resourceKey]string{}
+		catalogOwners             = map[string]string{}
+		deploymentComponents      = map[string]string{}
+		catalogNames              []string
+		renderedCatalogs          = map[string]sets.Set[string]{}
+		matchedPatterns           = sets.New[string]()
 		errs                      []error
 	)
+	b.warnings = nil
+
+	if err := b.validateExcludedManifests(); err != nil {
+		return nil, nil, nil, nil, err
+	}
 
 	titler := cases.Title(language.English)
 	for _, subDirectory := range subDirectories {
 		var staticResourceFiles []string
+		staticResourceRanks := map[string]int{}
+		crdFiles := sets.New[string]()
+		nonForcedFiles := map[string]resourceapply.ConditionalFunction{}
+		nonForcedInformers := map[schema.GroupVersionResource]map[string]cache.SharedIndexInformer{}
 		namePrefix := strings.ReplaceAll(titler.String(subDirectory), "-", "")
 		if err := fs.WalkDir(b.Assets, subDirectory, func(path string, d fs.DirEntry, err error) error {
+			// report unreadable files and directories along with the other manifest
+			// errors; WalkDir carries on with the rest of the tree
 			if err != nil {
-				return err
+				errs = append(errs, fmt.Errorf("error walking assets %q: %w", path, err))
+				return nil
 			}
 
 			if d.IsDir() {
@@ -68,6 +144,11 @@ func (b *Builder) BuildControllers(subDirectories ...string) (map[string]factory
 			if filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml" {
 				return nil
 			}
+			if patterns := b.excludedManifestPatterns(path); len(patterns) > 0 {
+				klog.NewKlogr().WithName("builder").Info("manifest excluded from management", "file", path)
+				matchedPatterns.Insert(patterns...)
+				return nil
+			}
 
 			manifestData, err := fs.ReadFile(b.Assets, path)
 			if err != nil {
@@ -80,8 +161,28 @@ func (b *Builder) BuildControllers(subDirectories ...string) (map[string]factory
 				errs = append(errs, fmt.Errorf("error parsing manifest for file %q: %w", path, err))
 				return nil
 			}
+			// decoding already fails without a kind, but not without an apiVersion
+			if manifest.GetAPIVersion() == "" {
+				errs = append(errs, fmt.Errorf("invalid manifest for file %q: apiVersion of kind %q is not set", path, manifest.GetKind()))
+				return nil
+			}
 
 			manifestGVK := manifest.GroupVersionKind()
+			// ClusterCatalogs are cluster scoped and named alike in every API version, so
+			// two components rendering the same name would fight over a single object
+			isClusterCatalog := manifestGVK.Kind == "ClusterCatalog" && manifestGVK.Group == catalogdv1.GroupVersion.Group
+			if isClusterCatalog {
+				if owner, ok := catalogOwners[manifest.GetName()]; ok && owner != subDirectory {
+					errs = append(errs, fmt.Errorf("duplicate ClusterCatalog %q in file %q: it is already rendered by %q", manifest.GetName(), path, owner))
+					return nil
+				}
+				catalogOwners[manifest.GetName()] = subDirectory
+				if err := validateClusterCatalogSource(&manifest); err != nil {
+					errs = append(errs, fmt.Errorf("invalid manifest for file %q: %w", path, err))
+					return nil
+				}
+			}
+
 			// check our known mappings first. If there isn't one, fallback to discovery
 			restMapping, ok := b.KnownRESTMappings[manifestGVK]
 			if !ok {
@@ -91,6 +192,13 @@ func (b *Builder) BuildControllers(subDirectories ...string) (map[string]factory
 					return nil
 				}
 			}
+			key := resourceKey{gvk: manifestGVK, namespace: manifest.GetNamespace(), name: manifest.GetName()}
+			if owner, ok := owners[key]; ok && owner != subDirectory {
+				errs = append(errs, fmt.Errorf("conflicting manifest for file %q: %s is already rendered by %q", path, key, owner))
+				return nil
+			}
+			owners[key] = subDirectory
+
 			relatedObjects = append(relatedObjects, configv1.ObjectReference{
 				Group:     restMapping.GroupVersionKind.Group,
 				Resource:  restMapping.Resource.Resource,
@@ -100,6 +208,28 @@ func (b *Builder) BuildControllers(subDirectories ...string) (map[string]factory
 
 			if manifestGVK.Kind == "Deployment" && manifestGVK.Group == "apps" {
 				controllerName := controllerNameForObject(namePrefix, &manifest)
+				var namespaceInformers informers.SharedInformerFactory
+				if b.Clients.OperandKubeInformersForNamespaces != nil {
+					namespaceInformers = b.Clients.OperandKubeInformersForNamespaces.InformersFor(manifest.GetNamespace())
+				}
+				if namespaceInformers == nil {
+					errs = append(errs, fmt.Errorf("no informers for the namespace %q of the Deployment in file %q", manifest.GetNamespace(), path))
+					return nil
+				}
+				serviceAccounts := namespaceInformers.Core().V1().ServiceAccounts()
+				deploymentHooks := append(
+					[]deploymentcontroller.DeploymentHookFunc{
+						requireServiceAccountHook(serviceAccounts.Lister()),
+						updateDeploymentProxyHook(b.Clients.ProxyClient, b.OperandConfigs[subDirectory].Proxy),
+						UpdateDeploymentTopologyHook(b.Clients.ConfigInformerFactory.Config().V1().Infrastructures().Lister()),
+						ownershipLabelsHook,
+					},
+					b.OperandConfigs[subDirectory].deploymentHooks()...,
+				)
+				if b.OperatorVersion != "" {
+					deploymentHooks = append(deploymentHooks, operatorVersionHook(b.OperatorVersion, b.Clients.KubeInformerFactory.Apps().V1().Deployments().Lister()))
+				}
+				deploymentHooks = append(deploymentHooks, forceReapplyHook(b.Clients.OperatorClient.GetAnnotation, b.Clients.KubeInformerFactory.Apps().V1().Deployments().Lister()))
 				deploymentControllers[controllerName] = deploymentcontroller.NewDeploymentController(
 					controllerName,
 					manifestData,
@@ -109,58 +239,553 @@ func (b *Builder) BuildControllers(subDirectories ...string) (map[string]factory
 					b.Clients.KubeInformerFactory.Apps().V1().Deployments(),
 					[]factory.Informer{
 						b.Clients.ProxyClient.Informer(),
+						b.Clients.ConfigInformerFactory.Config().V1().Infrastructures().Informer(),
+						requiredServiceAccountInformer(serviceAccounts.Informer(), &manifest),
+						b.Clients.ConfigInformerFactory.Config().V1().Networks().Informer(),
 					},
-					[]deploymentcontroller.ManifestHookFunc{
+					append([]deploymentcontroller.ManifestHookFunc{
 						replaceVerbosityHook("${LOG_VERBOSITY}"),
-						replaceImageHook("${CATALOGD_IMAGE}", "CATALOGD_IMAGE"),
-						replaceImageHook("${OPERATOR_CONTROLLER_IMAGE}", "OPERATOR_CONTROLLER_IMAGE"),
-						replaceImageHook("${KUBE_RBAC_PROXY_IMAGE}", "KUBE_RBAC_PROXY_IMAGE"),
-					},
-					UpdateDeploymentProxyHook(b.Clients.ProxyClient),
+						replaceBindHostHook(bindHostPlaceholder, b.Clients.ConfigInformerFactory.Config().V1().Networks().Lister()),
+					}, imageHooks()...),
+					deploymentHooks...,
 				)
+				deploymentComponents[controllerName] = subDirectory
 				return nil
 			}
 
-			if manifestGVK.Kind == "ClusterCatalog" && manifestGVK.Group == catalogdv1.GroupVersion.Group {
+			if isClusterCatalog {
 				controllerName := controllerNameForObject(namePrefix, &manifest)
+				catalog := manifest.DeepCopy()
+				if err := b.OperandConfigs[subDirectory].ClusterCatalogs[catalog.GetName()].applyTo(catalog); err != nil {
+					errs = append(errs, fmt.Errorf("error applying operand config to manifest for file %q: %w", path, err))
+					return nil
+				}
+				clusterCatalogManifest, err := withManagedByAnnotation(catalog)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("error annotating manifest for file %q: %w", path, err))
+					return nil
+				}
+				catalogNames = append(catalogNames, manifest.GetName())
+				if renderedCatalogs[subDirectory] == nil {
+					renderedCatalogs[subDirectory] = sets.New[string]()
+				}
+				renderedCatalogs[subDirectory].Insert(manifest.GetName())
 				clusterCatalogControllers[controllerName] = NewDynamicRequiredManifestController(
 					controllerName,
-					manifestData,
+					clusterCatalogManifest,
 					types.NamespacedName{
 						Namespace: manifest.GetNamespace(),
 						Name:      manifest.GetName(),
 					},
 					catalogdv1.GroupVersion.WithResource("clustercatalogs"),
+					b.ReportOnlyClusterCatalogs.Has(manifest.GetName()),
 					b.Clients.OperatorClient,
 					b.Clients.DynamicClient,
 					b.Clients.ClusterCatalogClient,
 					b.ControllerContext.EventRecorder.ForComponent(controllerName),
+					b.SlowSyncThreshold,
+					b.ApplyLogVerbosity,
 				)
 				return nil
 			}
 
+			if manifestGVK.Kind == "CustomResourceDefinition" && manifestGVK.Group == apiextensionsv1.GroupName {
+				crdFiles.Insert(path)
+			} else if b.NonForcedStaticResourceKinds.Has(manifestGVK.Kind) {
+				informer := b.Clients.DynamicInformersForNamespace(manifest.GetNamespace()).ForResource(restMapping.Resource)
+				if nonForcedInformers[restMapping.Resource] == nil {
+					nonForcedInformers[restMapping.Resource] = map[string]cache.SharedIndexInformer{}
+				}
+				nonForcedInformers[restMapping.Resource][manifest.GetNamespace()] = informer.Informer()
+				nonForcedFiles[path] = nonForcedApplyFunc(path, manifest.DeepCopy(), informer.Informer().HasSynced, nonForcedLister(informer.Lister(), manifest.GetNamespace()).Get)
+			}
 			staticResourceFiles = append(staticResourceFiles, path)
+			staticResourceRanks[path] = applyOrderRank(manifestGVK)
 			return nil
 		}); err != nil {
 			return nil, nil, nil, nil, err
 		}
+		sortByApplyOrder(staticResourceFiles, staticResourceRanks)
 
 		if len(staticResourceFiles) > 0 {
-			controllerName := fmt.Sprintf("%sStaticResources", namePrefix)
-			staticResourceControllers[controllerName] = staticresourcecontroller.NewStaticResourceController(
-				controllerName,
-				func(name string) ([]byte, error) { return fs.ReadFile(b.Assets, name) },
-				staticResourceFiles,
-				b.Clients.ClientHolder(),
-				b.Clients.OperatorClient,
-				b.ControllerContext.EventRecorder.ForComponent(controllerName),
-			)
+			fileSet := staticResourceFileSet{
+				controllerName: fmt.Sprintf("%sStaticResources", namePrefix),
+				files:          staticResourceFiles,
+				crdFiles:       crdFiles,
+				nonForcedFiles: nonForcedFiles,
+			}
+			for _, byNamespace := range nonForcedInformers {
+				for _, informer := range byNamespace {
+					fileSet.informers = append(fileSet.informers, informer)
+				}
+			}
+			staticResources = append(staticResources, fileSet)
 		}
 	}
 	if len(errs) > 0 {
 		return nil, nil, nil, nil, fmt.Errorf("error building controllers: %w", errors.Join(errs...))
 	}
-	return staticResourceControllers, deploymentControllers, clusterCatalogControllers, relatedObjects, nil
+	b.warnings = b.renderWarnings(subDirectories, renderedCatalogs, matchedPatterns)
+	for _, warning := range b.warnings {
+		klog.NewKlogr().WithName("builder").Info("render warning", "warning", warning)
+	}
+
+	if b.ClusterCatalogRolloutTimeout > 0 && b.ClusterCatalogGatedComponents.Len() > 0 {
+		gate := newClusterCatalogRolloutGate("OLMClusterCatalogRolloutGate", catalogNames, b.ClusterCatalogRolloutTimeout, b.Clients.ClusterCatalogClient, b.Clients.OperatorClient)
+		for name, component := range deploymentComponents {
+			if b.ClusterCatalogGatedComponents.Has(component) {
+				deploymentControllers[name] = gate.gate(deploymentControllers[name])
+			}
+		}
+	}
+
+	for _, sr := range staticResources {
+		c := newStaticResourceController(
+			sr.controllerName,
+			b.Assets,
+			sr.files,
+			sr.crdFiles,
+			sr.nonForcedFiles,
+			b.DeleteStaticResourcesOnRemoval,
+			b.Clients.ClientHolder(),
+			b.Clients.OperatorClient,
+			b.ControllerContext.EventRecorder.ForComponent(sr.controllerName),
+			b.ApplyLogVerbosity,
+		)
+		for _, informer := range sr.informers {
+			c = c.AddInformer(informer)
+		}
+		staticResourceControllers[sr.controllerName] = c
+	}
+	return staticResourceControllers, deploymentControllers, clusterCatalogControllers, uniqueObjectReferences(relatedObjects), nil
+}
+
+// Validate checks, before any controller is built, that the builder is configured and
+// that each of the given subdirectories of the assets holds manifests whose image
+// placeholders can be replaced. All the problems found are returned together.
+func (b *Builder) Validate(subDirectories ...string) error {
+	var errs []error
+	if b.Clients == nil {
+		errs = append(errs, errors.New("clients are not set"))
+	}
+	if b.ControllerContext == nil {
+		errs = append(errs, errors.New("controller context is not set"))
+	}
+	if err := b.validateExcludedManifests(); err != nil {
+		errs = append(errs, err)
+	}
+	if b.Assets == nil {
+		return errors.Join(append(errs, errors.New("assets are not set"))...)
+	}
+
+	for _, subDirectory := range subDirectories {
+		info, err := fs.Stat(b.Assets, subDirectory)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("assets of %q: %w", subDirectory, err))
+			continue
+		}
+		if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("assets of %q: %q is not a directory", subDirectory, subDirectory))
+			continue
+		}
+		manifests := 0
+		if err := fs.WalkDir(b.Assets, subDirectory, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error walking assets %q: %w", path, err))
+				return nil
+			}
+			if d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") || b.excludedManifest(path) {
+				return nil
+			}
+			manifests++
+			manifestData, err := fs.ReadFile(b.Assets, path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error reading assets file %q: %w", path, err))
+				return nil
+			}
+			for _, image := range operandImages {
+				if bytes.Contains(manifestData, []byte(image.placeholder)) && os.Getenv(image.envVar) == "" {
+					errs = append(errs, fmt.Errorf("assets file %q uses %s but the %s environment variable is not set", path, image.placeholder, image.envVar))
+				}
+			}
+			return nil
+		}); err != nil {
+			errs = append(errs, err)
+		}
+		if manifests == 0 {
+			errs = append(errs, fmt.Errorf("assets of %q: no manifests found", subDirectory))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid builder configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (b *Builder) validateExcludedManifests() error {
+	for _, pattern := range b.ExcludedManifests {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid excluded manifest pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// excludedManifest returns whether the asset file matches one of the excluded manifest
+// patterns, which BuildControllers has already validated.
+func (b *Builder) excludedManifest(file string) bool {
+	return len(b.excludedManifestPatterns(file)) > 0
+}
+
+// excludedManifestPatterns returns the excluded manifest patterns the asset file matches.
+func (b *Builder) excludedManifestPatterns(file string) []string {
+	var patterns []string
+	for _, pattern := range b.ExcludedManifests {
+		if matched, _ := path.Match(pattern, file); matched {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// RenderWarnings returns the non-fatal problems found by the last BuildControllers, such
+// as configuration that has no effect, sorted.
+func (b *Builder) RenderWarnings() []string {
+	return b.warnings
+}
+
+func (b *Builder) renderWarnings(subDirectories []string, renderedCatalogs map[string]sets.Set[string], matchedPatterns sets.Set[string]) []string {
+	var warnings []string
+	components := sets.New(subDirectories...)
+	for component, config := range b.OperandConfigs {
+		if !components.Has(component) {
+			warnings = append(warnings, fmt.Sprintf("the operand config of unknown component %q is ignored", component))
+			continue
+		}
+		for name := range config.ClusterCatalogs {
+			if !renderedCatalogs[component].Has(name) {
+				warnings = append(warnings, fmt.Sprintf("the operand config of ClusterCatalog %q is ignored, component %q does not render it", name, component))
+			}
+		}
+	}
+	for _, pattern := range b.ExcludedManifests {
+		if !matchedPatterns.Has(pattern) {
+			warnings = append(warnings, fmt.Sprintf("the excluded manifest pattern %q matches no manifest", pattern))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// uniqueObjectReferences returns the references with duplicates removed, keeping the
+// first occurrence. The same object can be rendered more than once, e.g. under
+// different API versions, but must only be related once.
+func uniqueObjectReferences(refs []configv1.ObjectReference) []configv1.ObjectReference {
+	seen := sets.New[configv1.ObjectReference]()
+	unique := make([]configv1.ObjectReference, 0, len(refs))
+	for _, ref := range refs {
+		if seen.Has(ref) {
+			continue
+		}
+		seen.Insert(ref)
+		unique = append(unique, ref)
+	}
+	return unique
+}
+
+// DeploymentNamespace returns the namespace of the Deployments rendered from the given
+// subdirectory of the assets, failing unless they all share a single namespace.
+// Excluded manifests are not rendered, so they are ignored.
+func (b *Builder) DeploymentNamespace(subDirectory string) (string, error) {
+	namespaces, err := b.DeploymentNamespaces(subDirectory)
+	if err != nil {
+		return "", err
+	}
+	if namespaces.Len() != 1 {
+		return "", fmt.Errorf("expected the Deployments of %q to share a single namespace, found %v", subDirectory, sets.List(namespaces))
+	}
+	return namespaces.UnsortedList()[0], nil
+}
+
+// DeploymentNamespaces returns the namespaces of the Deployments rendered from the
+// given subdirectories of the assets. Excluded manifests are not rendered, so they are
+// ignored.
+func (b *Builder) DeploymentNamespaces(subDirectories ...string) (sets.Set[string], error) {
+	namespaces := sets.New[string]()
+	for _, subDirectory := range subDirectories {
+		if err := fs.WalkDir(b.Assets, subDirectory, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+				return nil
+			}
+			if len(b.excludedManifestPatterns(path)) > 0 {
+				return nil
+			}
+			manifestData, err := fs.ReadFile(b.Assets, path)
+			if err != nil {
+				return fmt.Errorf("error reading assets file %q: %w", path, err)
+			}
+			var manifest unstructured.Unstructured
+			if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifestData), 4096).Decode(&manifest); err != nil {
+				return fmt.Errorf("error parsing manifest for file %q: %w", path, err)
+			}
+			if gvk := manifest.GroupVersionKind(); gvk.Kind == "Deployment" && gvk.Group == "apps" {
+				namespaces.Insert(manifest.GetNamespace())
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return namespaces, nil
+}
+
+// supportedClusterCatalogSourceTypes are the ClusterCatalog source types the operator
+// knows how to manage, e.g. to report the catalog images.
+var supportedClusterCatalogSourceTypes = sets.New(string(catalogdv1.SourceTypeImage))
+
+// validateClusterCatalogSource fails when the ClusterCatalog source type is not supported.
+func validateClusterCatalogSource(catalog *unstructured.Unstructured) error {
+	sourceType, _, err := unstructured.NestedString(catalog.Object, "spec", "source", "type")
+	if err != nil {
+		return fmt.Errorf("error reading the source type of ClusterCatalog %q: %w", catalog.GetName(), err)
+	}
+	if !supportedClusterCatalogSourceTypes.Has(sourceType) {
+		return fmt.Errorf("source type %q of ClusterCatalog %q is not supported, must be one of %q", sourceType, catalog.GetName(), sets.List(supportedClusterCatalogSourceTypes))
+	}
+	return nil
+}
+
+// ClusterCatalogImages returns the image references of the managed ClusterCatalogs found
+// in the given subdirectories of the assets, keyed by ClusterCatalog name.
+func (b *Builder) ClusterCatalogImages(subDirectories ...string) (map[string]string, error) {
+	images := map[string]string{}
+	for _, subDirectory := range subDirectories {
+		if err := fs.WalkDir(b.Assets, subDirectory, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") || b.excludedManifest(path) {
+				return nil
+			}
+			manifestData, err := fs.ReadFile(b.Assets, path)
+			if err != nil {
+				return fmt.Errorf("error reading assets file %q: %w", path, err)
+			}
+			var manifest unstructured.Unstructured
+			if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifestData), 4096).Decode(&manifest); err != nil {
+				return fmt.Errorf("error parsing manifest for file %q: %w", path, err)
+			}
+			if gvk := manifest.GroupVersionKind(); gvk.Kind != "ClusterCatalog" || gvk.Group != catalogdv1.GroupVersion.Group {
+				return nil
+			}
+			ref, _, err := unstructured.NestedString(manifest.Object, "spec", "source", "image", "ref")
+			if err != nil {
+				return fmt.Errorf("error reading the image of ClusterCatalog %q in file %q: %w", manifest.GetName(), path, err)
+			}
+			if ref != "" {
+				images[manifest.GetName()] = ref
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return images, nil
+}
+
+const (
+	// managedByAnnotation marks the ClusterCatalogs managed by this operator. It is also
+	// the key of the label marking the operand Deployments.
+	managedByAnnotation = "olm.openshift.io/managed-by"
+	managedByValue      = "cluster-olm-operator"
+)
+
+// operandDeploymentLabels identify the operand Deployments as managed by this operator.
+var operandDeploymentLabels = map[string]string{
+	"app.kubernetes.io/part-of": "olm",
+	managedByAnnotation:         managedByValue,
+}
+
+// ownershipLabelsHook sets the ownership labels on the Deployment. The deployment
+// controller merges the labels of the rendered metadata into the live Deployment on
+// every sync, so stripped labels are restored while labels added by others are kept.
+// They are not set on the pod template, whose changes would roll out the pods.
+func ownershipLabelsHook(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+	if deployment.Labels == nil {
+		deployment.Labels = map[string]string{}
+	}
+	for key, value := range operandDeploymentLabels {
+		deployment.Labels[key] = value
+	}
+	return nil
+}
+
+// withManagedByAnnotation returns the manifest with the ownership annotation added.
+// Since only the fields present in the manifest are enforced, annotations added by
+// users to the live object are left alone.
+func withManagedByAnnotation(manifest *unstructured.Unstructured) ([]byte, error) {
+	annotated := manifest.DeepCopy()
+	annotations := annotated.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[managedByAnnotation] = managedByValue
+	annotated.SetAnnotations(annotations)
+	return annotated.MarshalJSON()
+}
+
+// resourceKey identifies a rendered manifest across all subdirectories.
+type resourceKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+func (k resourceKey) String() string {
+	if k.namespace == "" {
+		return fmt.Sprintf("%s %q", k.gvk, k.name)
+	}
+	return fmt.Sprintf("%s %s/%s", k.gvk, k.namespace, k.name)
+}
+
+// applyOrderRank ranks the kinds other resources depend on first: CRDs, which custom
+// resources need, then Namespaces, which namespaced resources need.
+func applyOrderRank(gvk schema.GroupVersionKind) int {
+	switch {
+	case gvk.Group == apiextensionsv1.GroupName && gvk.Kind == "CustomResourceDefinition":
+		return 0
+	case gvk.Group == "" && gvk.Kind == "Namespace":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// sortByApplyOrder sorts the files by rank, keeping the walk order of files of the
+// same rank, so that the static resource controller applies dependencies first.
+func sortByApplyOrder(files []string, ranks map[string]int) {
+	sort.SliceStable(files, func(i, j int) bool { return ranks[files[i]] < ranks[files[j]] })
+}
+
+// staticResourceFileSet holds the static resource files collected for a subdirectory.
+type staticResourceFileSet struct {
+	controllerName string
+	files          []string
+	crdFiles       sets.Set[string]
+	nonForcedFiles map[string]resourceapply.ConditionalFunction
+	// informers watch the resources of the nonForcedFiles, so that they are synced
+	// before the controller runs and re-created as soon as they are deleted.
+	informers []cache.SharedIndexInformer
+}
+
+// newStaticResourceController returns a static resource controller for the given files.
+// When deleteOnRemoval is set, every file not in crdFiles is only applied while the
+// operator is not Removed, and is deleted once it is. The files in nonForcedFiles are
+// only applied while their check allows it.
+func newStaticResourceController(name string, assets fs.FS, files []string, crdFiles sets.Set[string], nonForcedFiles map[string]resourceapply.ConditionalFunction, deleteOnRemoval bool, clientHolder *resourceapply.ClientHolder, operatorClient v1helpers.OperatorClient, recorder events.Recorder, applyLogVerbosity int) *staticresourcecontroller.StaticResourceController {
+	logger := klog.NewKlogr().WithName(name)
+	assetFunc := func(file string) ([]byte, error) {
+		manifest, err := fs.ReadFile(assets, file)
+		if err == nil {
+			logStaticResourceApply(logger, applyLogVerbosity, file, manifest)
+		}
+		return manifest, err
+	}
+
+	removed := func() bool {
+		operatorSpec, _, _, err := operatorClient.GetOperatorState()
+		if err != nil {
+			return false
+		}
+		return operatorSpec.ManagementState == operatorv1.Removed
+	}
+	never := func() bool { return false }
+
+	var retainedFiles, removableFiles, nonForced []string
+	for _, file := range files {
+		switch {
+		case nonForcedFiles[file] != nil:
+			nonForced = append(nonForced, file)
+		case crdFiles.Has(file) || !deleteOnRemoval:
+			retainedFiles = append(retainedFiles, file)
+		default:
+			removableFiles = append(removableFiles, file)
+		}
+	}
+
+	c := staticresourcecontroller.NewStaticResourceController(name, assetFunc, retainedFiles, clientHolder, operatorClient, recorder)
+	if len(removableFiles) > 0 {
+		c = c.WithConditionalResources(assetFunc, removableFiles, func() bool { return !removed() }, removed)
+	}
+	for _, file := range nonForced {
+		shouldApply := nonForcedFiles[file]
+		if !deleteOnRemoval {
+			// an explicit shouldDelete is required, it otherwise defaults to !shouldCreate
+			c = c.WithConditionalResources(assetFunc, []string{file}, shouldApply, never)
+			continue
+		}
+		c = c.WithConditionalResources(assetFunc, []string{file}, func() bool { return !removed() && shouldApply() }, removed)
+	}
+	return c
+}
+
+// logStaticResourceApply logs the manifest of a static resource read to be applied.
+// Static resources are not applied server-side, so no field manager is logged.
+func logStaticResourceApply(logger logr.Logger, verbosity int, file string, manifest []byte) {
+	if verbosity <= 0 {
+		return
+	}
+	var obj unstructured.Unstructured
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096).Decode(&obj); err != nil {
+		return
+	}
+	logApply(logger.WithValues("file", file), verbosity, obj.GroupVersionKind().String(), obj.GetNamespace(), obj.GetName(), "", manifest)
+}
+
+// nonForcedLister returns the lister of the resources in the namespace, or of the
+// cluster-scoped resources when it is empty.
+func nonForcedLister(lister cache.GenericLister, namespace string) interface {
+	Get(name string) (runtime.Object, error)
+} {
+	if namespace == "" {
+		return lister
+	}
+	return lister.ByNamespace(namespace)
+}
+
+// nonForcedApplyFunc returns a check allowing the manifest to be applied only while its
+// resource does not exist. The resource is read from an informer cache through getFunc,
+// and the manifest is not applied until hasSynced returns true. Drift of an existing
+// resource from the manifest is logged instead of being reverted.
+func nonForcedApplyFunc(path string, manifest *unstructured.Unstructured, hasSynced cache.InformerSynced, getFunc func(name string) (runtime.Object, error)) resourceapply.ConditionalFunction {
+	logger := klog.NewKlogr().WithName("builder").WithValues("file", path, "kind", manifest.GetKind(), "namespace", manifest.GetNamespace(), "name", manifest.GetName())
+	return func() bool {
+		// an unsynced cache would report an existing resource as missing
+		if !hasSynced() {
+			logger.V(2).Info("informer not synced yet, not applying the manifest")
+			return false
+		}
+		obj, err := getFunc(manifest.GetName())
+		if apierrors.IsNotFound(err) {
+			return true
+		}
+		if err != nil {
+			logger.Error(err, "failed to get the existing resource, not applying its manifest")
+			return false
+		}
+		existing, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			logger.Error(err, "failed to convert the existing resource, not applying its manifest")
+			return false
+		}
+		if !equality.Semantic.DeepDerivative(manifest.Object, existing) {
+			logger.Info("existing resource differs from its manifest, not overwriting it as its kind is not force-applied")
+		}
+		return false
+	}
 }
 
 type object interface {
@@ -186,6 +811,25 @@ func replaceVerbosityHook(placeholder string) deploymentcontroller.ManifestHookF
 	}
 }
 
+// operandImages are the image placeholders of the operand manifests, replaced by the
+// value of their environment variable.
+var operandImages = []struct {
+	placeholder string
+	envVar      string
+}{
+	{placeholder: "${CATALOGD_IMAGE}", envVar: "CATALOGD_IMAGE"},
+	{placeholder: "${OPERATOR_CONTROLLER_IMAGE}", envVar: "OPERATOR_CONTROLLER_IMAGE"},
+	{placeholder: "${KUBE_RBAC_PROXY_IMAGE}", envVar: "KUBE_RBAC_PROXY_IMAGE"},
+}
+
+func imageHooks() []deploymentcontroller.ManifestHookFunc {
+	hooks := make([]deploymentcontroller.ManifestHookFunc, 0, len(operandImages))
+	for _, image := range operandImages {
+		hooks = append(hooks, replaceImageHook(image.placeholder, image.envVar))
+	}
+	return hooks
+}
+
 func replaceImageHook(placeholder string, desiredImageEnvVar string) deploymentcontroller.ManifestHookFunc {
 	return func(_ *operatorv1.OperatorSpec, deployment []byte) ([]byte, error) {
 		replacer := strings.NewReplacer(placeholder, os.Getenv(desiredImageEnvVar))
@@ -217,8 +861,33 @@ func setContainerEnv(con *corev1.Container, envs []corev1.EnvVar) error {
 	return nil
 }
 
+// proxyExcludedContainersAnnotation is a pod template annotation holding a
+// comma-separated list of container names that must not receive the cluster
+// proxy environment.
+const proxyExcludedContainersAnnotation = "olm.openshift.io/proxy-excluded-containers"
+
+func proxyExcludedContainers(annotations map[string]string) sets.Set[string] {
+	excluded := sets.New[string]()
+	for _, name := range strings.Split(annotations[proxyExcludedContainersAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			excluded.Insert(name)
+		}
+	}
+	return excluded
+}
+
 func UpdateDeploymentProxyHook(pc clients.ProxyClientInterface) deploymentcontroller.DeploymentHookFunc {
+	return updateDeploymentProxyHook(pc, nil)
+}
+
+// updateDeploymentProxyHook injects the cluster proxy environment, with the
+// component's overrides applied, into the containers of the Deployment, and
+// mounts the proxy trust bundle when the cluster proxy has a trustedCA.
+func updateDeploymentProxyHook(pc clients.ProxyClientInterface, override *ProxyConfig) deploymentcontroller.DeploymentHookFunc {
 	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		if override != nil && override.Disabled {
+			return nil
+		}
 		klog.FromContext(context.Background()).WithName("builder").V(0).Info("Updating environment", "deployment", deployment.Name)
 		proxyConfig, err := pc.Get("cluster")
 		if err != nil {
@@ -226,23 +895,42 @@ func UpdateDeploymentProxyHook(pc clients.ProxyClientInterface) deploymentcontro
 		}
 
 		var errs []error
+		httpsProxy, httpProxy, noProxy := override.apply(proxyConfig.Status.HTTPSProxy, proxyConfig.Status.HTTPProxy, proxyConfig.Status.NoProxy)
 		vars := []corev1.EnvVar{
-			{Name: HTTPSProxy, Value: proxyConfig.Status.HTTPSProxy},
-			{Name: HTTPProxy, Value: proxyConfig.Status.HTTPProxy},
-			{Name: NoProxy, Value: proxyConfig.Status.NoProxy},
+			{Name: HTTPSProxy, Value: httpsProxy},
+			{Name: HTTPProxy, Value: httpProxy},
+			{Name: NoProxy, Value: noProxy},
+		}
+
+		trustedCA := proxyConfig.Spec.TrustedCA.Name != ""
+		if trustedCA {
+			addProxyTrustedCAVolume(&deployment.Spec.Template.Spec)
 		}
 
+		excluded := proxyExcludedContainers(deployment.Spec.Template.Annotations)
 		for i := range deployment.Spec.Template.Spec.InitContainers {
+			if excluded.Has(deployment.Spec.Template.Spec.InitContainers[i].Name) {
+				continue
+			}
 			err = setContainerEnv(&deployment.Spec.Template.Spec.InitContainers[i], vars)
 			if err != nil {
 				errs = append(errs, err)
 			}
+			if trustedCA {
+				mountProxyTrustedCA(&deployment.Spec.Template.Spec.InitContainers[i])
+			}
 		}
 		for i := range deployment.Spec.Template.Spec.Containers {
+			if excluded.Has(deployment.Spec.Template.Spec.Containers[i].Name) {
+				continue
+			}
 			err = setContainerEnv(&deployment.Spec.Template.Spec.Containers[i], vars)
 			if err != nil {
 				errs = append(errs, err)
 			}
+			if trustedCA {
+				mountProxyTrustedCA(&deployment.Spec.Template.Spec.Containers[i])
+			}
 		}
 		if len(errs) > 0 {
 			return errors.Join(errs...)
@@ -1,12 +1,36 @@
 package controller
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
 )
 
 func TestControllerNameForObject(t *testing.T) {
@@ -137,3 +161,1007 @@ func TestUpdateEnv(t *testing.T) {
 	// Make sure the Deployment is unchanged
 	check()
 }
+
+func TestUpdateEnvExcludedContainers(t *testing.T) {
+	mpc := MockProxyClient{
+		Proxy: configv1.Proxy{
+			Status: configv1.ProxyStatus{
+				HTTPProxy:  HTTPProxy,
+				HTTPSProxy: HTTPSProxy,
+				NoProxy:    NoProxy,
+			},
+		},
+	}
+
+	dep := appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{proxyExcludedContainersAnnotation: "sidecar, init-sidecar"},
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "init-sidecar"},
+					},
+					Containers: []corev1.Container{
+						{Name: "manager"},
+						{Name: "sidecar", Env: []corev1.EnvVar{{Name: "FOO", Value: "bar"}}},
+					},
+				},
+			},
+		},
+	}
+
+	if err := UpdateDeploymentProxyHook(&mpc)(nil, &dep); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if env := dep.Spec.Template.Spec.Containers[0].Env; len(env) != 3 {
+		t.Errorf("expected proxy environment on container %q, got: %+v", "manager", env)
+	}
+	if env := dep.Spec.Template.Spec.Containers[1].Env; len(env) != 1 || env[0].Name != "FOO" {
+		t.Errorf("expected environment of excluded container %q to be untouched, got: %+v", "sidecar", env)
+	}
+	if env := dep.Spec.Template.Spec.InitContainers[0].Env; len(env) != 0 {
+		t.Errorf("expected no proxy environment on excluded init container %q, got: %+v", "init-sidecar", env)
+	}
+}
+
+func TestUpdateEnvComponentOverrides(t *testing.T) {
+	mpc := MockProxyClient{
+		Proxy: configv1.Proxy{
+			Status: configv1.ProxyStatus{
+				HTTPProxy:  "http://cluster-proxy:3128",
+				HTTPSProxy: "http://cluster-proxy:3128",
+				NoProxy:    ".cluster.local",
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		override *ProxyConfig
+		expected []corev1.EnvVar
+	}{
+		{
+			name: "cluster proxy",
+			expected: []corev1.EnvVar{
+				{Name: HTTPSProxy, Value: "http://cluster-proxy:3128"},
+				{Name: HTTPProxy, Value: "http://cluster-proxy:3128"},
+				{Name: NoProxy, Value: ".cluster.local"},
+			},
+		},
+		{
+			name:     "component overrides",
+			override: &ProxyConfig{HTTPSProxy: "http://registry-proxy:3128", NoProxy: ".cluster.local,registry.example.com"},
+			expected: []corev1.EnvVar{
+				{Name: HTTPSProxy, Value: "http://registry-proxy:3128"},
+				{Name: HTTPProxy, Value: "http://cluster-proxy:3128"},
+				{Name: NoProxy, Value: ".cluster.local,registry.example.com"},
+			},
+		},
+		{
+			name:     "component excluded",
+			override: &ProxyConfig{Disabled: true},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "manager"}},
+						},
+					},
+				},
+			}
+			if err := updateDeploymentProxyHook(&mpc, tc.override)(nil, &dep); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if env := dep.Spec.Template.Spec.Containers[0].Env; !reflect.DeepEqual(tc.expected, env) {
+				t.Errorf("expected environment %+v, got %+v", tc.expected, env)
+			}
+		})
+	}
+}
+
+func TestUpdateEnvProxyTrustedCA(t *testing.T) {
+	mpc := MockProxyClient{
+		Proxy: configv1.Proxy{
+			Spec: configv1.ProxySpec{TrustedCA: configv1.ConfigMapNameReference{Name: "user-ca-bundle"}},
+			Status: configv1.ProxyStatus{
+				HTTPSProxy: "http://cluster-proxy:3128",
+			},
+		},
+	}
+
+	dep := appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{proxyExcludedContainersAnnotation: "sidecar"},
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init"}},
+					Containers: []corev1.Container{
+						{Name: "manager", Env: []corev1.EnvVar{{Name: SSLCertDir, Value: "/var/certs"}}},
+						{Name: "sidecar"},
+					},
+				},
+			},
+		},
+	}
+
+	if err := UpdateDeploymentProxyHook(&mpc)(nil, &dep); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedVolumes := []corev1.Volume{{
+		Name: proxyTrustedCAVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: proxyTrustedCABundleConfigMap},
+				Items:                []corev1.KeyToPath{{Key: "ca-bundle.crt", Path: "tls-ca-bundle.pem"}},
+				Optional:             ptr.To(true),
+			},
+		},
+	}}
+	if !reflect.DeepEqual(expectedVolumes, dep.Spec.Template.Spec.Volumes) {
+		t.Errorf("expected volumes %+v, got %+v", expectedVolumes, dep.Spec.Template.Spec.Volumes)
+	}
+	expectedMounts := []corev1.VolumeMount{{Name: proxyTrustedCAVolume, MountPath: proxyTrustedCAMountPath, ReadOnly: true}}
+	for _, tc := range []struct {
+		container corev1.Container
+		certDir   string
+	}{
+		{container: dep.Spec.Template.Spec.InitContainers[0], certDir: proxyTrustedCAMountPath},
+		{container: dep.Spec.Template.Spec.Containers[0], certDir: "/var/certs:" + proxyTrustedCAMountPath},
+	} {
+		if !reflect.DeepEqual(expectedMounts, tc.container.VolumeMounts) {
+			t.Errorf("expected container %q volume mounts %+v, got %+v", tc.container.Name, expectedMounts, tc.container.VolumeMounts)
+		}
+		var certDir string
+		for _, env := range tc.container.Env {
+			if env.Name == SSLCertDir {
+				certDir = env.Value
+			}
+		}
+		if certDir != tc.certDir {
+			t.Errorf("expected container %q %s %q, got %q", tc.container.Name, SSLCertDir, tc.certDir, certDir)
+		}
+	}
+	if sidecar := dep.Spec.Template.Spec.Containers[1]; len(sidecar.VolumeMounts) != 0 || len(sidecar.Env) != 0 {
+		t.Errorf("expected excluded container %q to be untouched, got %+v", sidecar.Name, sidecar)
+	}
+
+	// without a trustedCA, nothing is mounted
+	mpc.Spec.TrustedCA.Name = ""
+	dep.Spec.Template.Spec = corev1.PodSpec{Containers: []corev1.Container{{Name: "manager"}}}
+	if err := UpdateDeploymentProxyHook(&mpc)(nil, &dep); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if podSpec := dep.Spec.Template.Spec; len(podSpec.Volumes) != 0 || len(podSpec.Containers[0].VolumeMounts) != 0 {
+		t.Errorf("expected no trusted CA volume without a trustedCA, got %+v", podSpec)
+	}
+}
+
+func TestStaticResourceControllerDeleteOnRemoval(t *testing.T) {
+	const (
+		configMapFile = "catalogd/configmap.yaml"
+		crdFile       = "catalogd/crd.yaml"
+	)
+	assets := fstest.MapFS{
+		configMapFile: &fstest.MapFile{Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: test-namespace
+`)},
+		crdFile: &fstest.MapFile{Data: []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: tests.example.com
+spec:
+  group: example.com
+  names:
+    kind: Test
+    plural: tests
+  scope: Cluster
+`)},
+	}
+
+	for _, tc := range []struct {
+		name            string
+		deleteOnRemoval bool
+		managementState operatorv1.ManagementState
+		expectConfigMap bool
+	}{
+		{
+			name:            "flag disabled, removed, resources retained",
+			deleteOnRemoval: false,
+			managementState: operatorv1.Removed,
+			expectConfigMap: true,
+		},
+		{
+			name:            "flag enabled, removed, non-CRD resources deleted",
+			deleteOnRemoval: true,
+			managementState: operatorv1.Removed,
+			expectConfigMap: false,
+		},
+		{
+			name:            "flag enabled, managed, resources retained",
+			deleteOnRemoval: true,
+			managementState: operatorv1.Managed,
+			expectConfigMap: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			kubeClient := kubefake.NewSimpleClientset(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test-namespace"},
+			})
+			apiExtensionsClient := apiextensionsfake.NewSimpleClientset()
+			clientHolder := resourceapply.NewClientHolder().WithKubernetes(kubeClient).WithAPIExtensionsClient(apiExtensionsClient)
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: tc.managementState}, &operatorv1.OperatorStatus{}, nil)
+			recorder := events.NewInMemoryRecorder("test")
+
+			c := newStaticResourceController("Test", assets, []string{configMapFile, crdFile}, sets.New(crdFile), nil, tc.deleteOnRemoval, clientHolder, operatorClient, recorder, 0)
+			if err := c.Sync(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			_, err := kubeClient.CoreV1().ConfigMaps("test-namespace").Get(ctx, "test", metav1.GetOptions{})
+			switch {
+			case tc.expectConfigMap && err != nil:
+				t.Errorf("expected ConfigMap to be retained, got error: %v", err)
+			case !tc.expectConfigMap && !apierrors.IsNotFound(err):
+				t.Errorf("expected ConfigMap to be deleted, got error: %v", err)
+			}
+
+			if _, err := apiExtensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, "tests.example.com", metav1.GetOptions{}); err != nil {
+				t.Errorf("expected CRD to be retained, got error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStaticResourceControllerNonForcedApply(t *testing.T) {
+	configMap := func(name, value string) []byte {
+		return []byte(fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+  namespace: test-namespace
+data:
+  key: %s
+`, name, value))
+	}
+	assets := fstest.MapFS{
+		"catalogd/forced.yaml":     &fstest.MapFile{Data: configMap("forced", "manifest")},
+		"catalogd/non-forced.yaml": &fstest.MapFile{Data: configMap("non-forced", "manifest")},
+		"catalogd/missing.yaml":    &fstest.MapFile{Data: configMap("missing", "manifest")},
+	}
+
+	for _, tc := range []struct {
+		name            string
+		deleteOnRemoval bool
+		unsynced        bool
+	}{
+		{name: "delete on removal disabled"},
+		{name: "delete on removal enabled", deleteOnRemoval: true},
+		{name: "informer not synced, missing resources not created yet", unsynced: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			existing := []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: "forced", Namespace: "test-namespace"}, Data: map[string]string{"key": "customized"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "non-forced", Namespace: "test-namespace"}, Data: map[string]string{"key": "customized"}},
+			}
+			kubeClient := kubefake.NewSimpleClientset(existing[0], existing[1])
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for _, cm := range existing {
+				obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cm)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := indexer.Add(&unstructured.Unstructured{Object: obj}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			lister := cache.NewGenericLister(indexer, corev1.Resource("configmaps"))
+			hasSynced := func() bool { return !tc.unsynced }
+			clientHolder := resourceapply.NewClientHolder().WithKubernetes(kubeClient)
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			recorder := events.NewInMemoryRecorder("test")
+
+			nonForced := map[string]resourceapply.ConditionalFunction{}
+			for _, file := range []string{"catalogd/non-forced.yaml", "catalogd/missing.yaml"} {
+				var manifest unstructured.Unstructured
+				if err := yaml.Unmarshal(assets[file].Data, &manifest.Object); err != nil {
+					t.Fatal(err)
+				}
+				nonForced[file] = nonForcedApplyFunc(file, &manifest, hasSynced, nonForcedLister(lister, "test-namespace").Get)
+			}
+
+			files := []string{"catalogd/forced.yaml", "catalogd/non-forced.yaml", "catalogd/missing.yaml"}
+			c := newStaticResourceController("Test", assets, files, sets.New[string](), nonForced, tc.deleteOnRemoval, clientHolder, operatorClient, recorder, 0)
+			if err := c.Sync(ctx, factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := map[string]string{
+				"forced":     "manifest",
+				"non-forced": "customized",
+				"missing":    "manifest",
+			}
+			if tc.unsynced {
+				delete(expected, "missing")
+				if _, err := kubeClient.CoreV1().ConfigMaps("test-namespace").Get(ctx, "missing", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+					t.Errorf("expected ConfigMap %q not to be created before the informer synced, got error: %v", "missing", err)
+				}
+			}
+			for name, expected := range expected {
+				cm, err := kubeClient.CoreV1().ConfigMaps("test-namespace").Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("expected ConfigMap %q to exist, got error: %v", name, err)
+					continue
+				}
+				if actual := cm.Data["key"]; actual != expected {
+					t.Errorf("expected ConfigMap %q to have value %q, got %q", name, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildControllersCrossComponentConflict(t *testing.T) {
+	clusterRole := []byte(`apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: shared
+`)
+	clusterRoleGVK := schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	b := Builder{
+		Assets: fstest.MapFS{
+			"catalogd/clusterrole.yaml":            &fstest.MapFile{Data: clusterRole},
+			"operator-controller/clusterrole.yaml": &fstest.MapFile{Data: clusterRole},
+		},
+		KnownRESTMappings: map[schema.GroupVersionKind]*meta.RESTMapping{
+			clusterRoleGVK: {
+				Resource:         clusterRoleGVK.GroupVersion().WithResource("clusterroles"),
+				GroupVersionKind: clusterRoleGVK,
+				Scope:            meta.RESTScopeRoot,
+			},
+		},
+	}
+
+	_, _, _, _, err := b.BuildControllers("catalogd", "operator-controller")
+	if err == nil {
+		t.Fatal("expected an error for a resource rendered by two components")
+	}
+	for _, expected := range []string{`"operator-controller/clusterrole.yaml"`, `"shared"`, `"catalogd"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %s, got: %v", expected, err)
+		}
+	}
+}
+
+func TestBuildControllersDuplicateClusterCatalog(t *testing.T) {
+	clusterCatalog := func(version string) []byte {
+		return []byte(`apiVersion: olm.operatorframework.io/` + version + `
+kind: ClusterCatalog
+metadata:
+  name: openshift-redhat-operators
+spec:
+  source:
+    type: Image
+`)
+	}
+	b := Builder{
+		Assets: fstest.MapFS{
+			"catalogd/catalog.yaml":            &fstest.MapFile{Data: clusterCatalog("v1")},
+			"operator-controller/catalog.yaml": &fstest.MapFile{Data: clusterCatalog("v1alpha1")},
+		},
+		// duplicates are detected before, and regardless of, the RESTMapping lookup
+		Clients: &clients.Clients{RESTMapper: meta.NewDefaultRESTMapper(nil)},
+	}
+
+	_, _, _, _, err := b.BuildControllers("catalogd", "operator-controller")
+	if err == nil {
+		t.Fatal("expected an error for a ClusterCatalog rendered by two components")
+	}
+	expected := `duplicate ClusterCatalog "openshift-redhat-operators" in file "operator-controller/catalog.yaml": it is already rendered by "catalogd"`
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected error to contain %s, got: %v", expected, err)
+	}
+}
+
+func TestValidateClusterCatalogSource(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		source        map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:   "image",
+			source: map[string]interface{}{"type": "Image", "image": map[string]interface{}{"ref": "registry.redhat.io/redhat/redhat-operator-index:v4.18"}},
+		},
+		{
+			name:          "unsupported type",
+			source:        map[string]interface{}{"type": "Git"},
+			expectedError: `source type "Git" of ClusterCatalog "catalog" is not supported, must be one of ["Image"]`,
+		},
+		{
+			name:          "missing type",
+			expectedError: `source type "" of ClusterCatalog "catalog" is not supported`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			catalog := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			catalog.SetName("catalog")
+			if tc.source != nil {
+				if err := unstructured.SetNestedMap(catalog.Object, tc.source, "spec", "source"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := validateClusterCatalogSource(catalog)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestBuildControllersUnsupportedClusterCatalogSource(t *testing.T) {
+	b := Builder{
+		Assets: fstest.MapFS{
+			"catalogd/catalog.yaml": &fstest.MapFile{Data: []byte(`apiVersion: olm.operatorframework.io/v1
+kind: ClusterCatalog
+metadata:
+  name: openshift-redhat-operators
+spec:
+  source:
+    type: ConfigMap
+`)},
+		},
+	}
+
+	_, _, _, _, err := b.BuildControllers("catalogd")
+	expected := `invalid manifest for file "catalogd/catalog.yaml": source type "ConfigMap" of ClusterCatalog "openshift-redhat-operators" is not supported`
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error containing %q, got %v", expected, err)
+	}
+}
+
+func TestWithManagedByAnnotation(t *testing.T) {
+	manifest := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(requiredYAML), &manifest.Object); err != nil {
+		t.Fatal(err)
+	}
+	manifest.SetAnnotations(map[string]string{"existing": "annotation"})
+
+	annotated, err := withManagedByAnnotation(manifest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var result unstructured.Unstructured
+	if err := result.UnmarshalJSON(annotated); err != nil {
+		t.Fatal(err)
+	}
+	if value := result.GetAnnotations()[managedByAnnotation]; value != managedByValue {
+		t.Errorf("expected annotation %s=%s, got %q", managedByAnnotation, managedByValue, value)
+	}
+	if value := result.GetAnnotations()["existing"]; value != "annotation" {
+		t.Errorf("expected manifest annotations to be preserved, got %v", result.GetAnnotations())
+	}
+
+	existing := func(annotations map[string]interface{}) *unstructured.Unstructured {
+		obj := manifest.DeepCopy()
+		obj.SetAnnotations(nil)
+		if annotations != nil {
+			if err := unstructured.SetNestedMap(obj.Object, annotations, "metadata", "annotations"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return obj
+	}
+
+	for _, tc := range []struct {
+		name         string
+		existing     *unstructured.Unstructured
+		expectUpdate bool
+	}{
+		{
+			name:         "annotation missing, update needed",
+			existing:     existing(map[string]interface{}{"existing": "annotation"}),
+			expectUpdate: true,
+		},
+		{
+			name: "annotation present alongside user annotations, no update needed",
+			existing: existing(map[string]interface{}{
+				"existing":          "annotation",
+				managedByAnnotation: managedByValue,
+				"user/annotation":   "value",
+			}),
+			expectUpdate: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			needsUpdate, err := unstructuredShouldUpdateFunc()(annotated, tc.existing)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if needsUpdate != tc.expectUpdate {
+				t.Errorf("expected needsUpdate: %v, got: %v", tc.expectUpdate, needsUpdate)
+			}
+		})
+	}
+}
+
+func TestDeploymentNamespace(t *testing.T) {
+	deployment := func(namespace string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(fmt.Sprintf(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  namespace: %s
+  name: controller-manager
+`, namespace))}
+	}
+	serviceAccount := &fstest.MapFile{Data: []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  namespace: other
+  name: controller-manager
+`)}
+
+	for _, tc := range []struct {
+		name        string
+		assets      fstest.MapFS
+		excluded    []string
+		expected    string
+		expectedErr bool
+	}{
+		{
+			name: "non-default namespace",
+			assets: fstest.MapFS{
+				"operator-controller/deployment.yaml":     deployment("alternate-operator-controller"),
+				"operator-controller/serviceaccount.yaml": serviceAccount,
+				"catalogd/deployment.yaml":                deployment("openshift-catalogd"),
+			},
+			expected: "alternate-operator-controller",
+		},
+		{
+			name: "no deployment",
+			assets: fstest.MapFS{
+				"operator-controller/serviceaccount.yaml": serviceAccount,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "deployments in different namespaces",
+			assets: fstest.MapFS{
+				"operator-controller/a.yaml": deployment("a"),
+				"operator-controller/b.yaml": deployment("b"),
+			},
+			expectedErr: true,
+		},
+		{
+			name: "excluded deployment",
+			assets: fstest.MapFS{
+				"operator-controller/a.yaml": deployment("a"),
+				"operator-controller/b.yaml": deployment("b"),
+			},
+			excluded: []string{"operator-controller/b.yaml"},
+			expected: "a",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := Builder{Assets: tc.assets, ExcludedManifests: tc.excluded}
+			namespace, err := b.DeploymentNamespace("operator-controller")
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatalf("expected an error, got namespace %q", namespace)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if namespace != tc.expected {
+				t.Errorf("expected namespace %q, got %q", tc.expected, namespace)
+			}
+		})
+	}
+}
+
+func TestDeploymentNamespaces(t *testing.T) {
+	b := Builder{
+		Assets: fstest.MapFS{
+			"catalogd/deployment.yaml": &fstest.MapFile{Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  namespace: openshift-catalogd
+  name: catalogd-controller-manager
+`)},
+			"operator-controller/deployment.yaml": &fstest.MapFile{Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  namespace: openshift-operator-controller
+  name: operator-controller-controller-manager
+`)},
+			"operator-controller/serviceaccount.yaml": &fstest.MapFile{Data: []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  namespace: other
+  name: operator-controller-controller-manager
+`)},
+		},
+	}
+	namespaces, err := b.DeploymentNamespaces("catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"openshift-catalogd", "openshift-operator-controller"}; !reflect.DeepEqual(expected, sets.List(namespaces)) {
+		t.Errorf("expected namespaces %v, got %v", expected, sets.List(namespaces))
+	}
+}
+
+func TestBuildControllersMissingAPIVersion(t *testing.T) {
+	b := Builder{
+		Assets: fstest.MapFS{
+			"catalogd/clusterrole.yaml": &fstest.MapFile{Data: []byte(`kind: ClusterRole
+metadata:
+  name: foo
+`)},
+		},
+	}
+	_, _, _, _, err := b.BuildControllers("catalogd")
+	if err == nil {
+		t.Fatal("expected an error for a manifest without apiVersion")
+	}
+	for _, expected := range []string{`"catalogd/clusterrole.yaml"`, `apiVersion of kind "ClusterRole" is not set`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %s, got: %v", expected, err)
+		}
+	}
+}
+
+func TestBuildControllersReportsAllManifestErrors(t *testing.T) {
+	b := Builder{
+		Assets: fstest.MapFS{
+			"catalogd/00-invalid.yaml": &fstest.MapFile{Data: []byte(`kind: [`)},
+			"catalogd/01-no-apiversion.yaml": &fstest.MapFile{Data: []byte(`kind: ClusterRole
+metadata:
+  name: foo
+`)},
+			"catalogd/02-unknown-kind.yaml": &fstest.MapFile{Data: []byte(`apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: foo
+`)},
+			"catalogd/03-invalid.yml": &fstest.MapFile{Data: []byte(`{"kind": 1}`)},
+		},
+		Clients: &clients.Clients{RESTMapper: meta.NewDefaultRESTMapper(nil)},
+	}
+	_, _, _, _, err := b.BuildControllers("catalogd", "operator-controller")
+	if err == nil {
+		t.Fatal("expected errors for the broken manifests")
+	}
+	for _, expected := range []string{
+		`error parsing manifest for file "catalogd/00-invalid.yaml"`,
+		`invalid manifest for file "catalogd/01-no-apiversion.yaml"`,
+		`error looking up RESTMapping for file "catalogd/02-unknown-kind.yaml"`,
+		`error parsing manifest for file "catalogd/03-invalid.yml"`,
+		// a missing component directory does not hide the errors of the others
+		`error walking assets "operator-controller"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %s, got: %v", expected, err)
+		}
+	}
+}
+
+func TestBuilderValidate(t *testing.T) {
+	deployment := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  namespace: openshift-catalogd
+  name: catalogd-controller-manager
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: ${CATALOGD_IMAGE}
+`)
+	serviceAccount := []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  namespace: openshift-operator-controller
+  name: operator-controller-controller-manager
+`)
+	validAssets := func() fstest.MapFS {
+		return fstest.MapFS{
+			"catalogd/deployment.yaml":                  &fstest.MapFile{Data: deployment},
+			"operator-controller/serviceaccount.yaml":   &fstest.MapFile{Data: serviceAccount},
+			"operator-controller/README.md":             &fstest.MapFile{Data: []byte("not a manifest")},
+			"operator-controller/nested/configmap.yaml": &fstest.MapFile{Data: serviceAccount},
+		}
+	}
+	for _, tc := range []struct {
+		name           string
+		builder        func() Builder
+		catalogdImage  string
+		expectedErrors []string
+	}{
+		{
+			name: "valid layout",
+			builder: func() Builder {
+				return Builder{Assets: validAssets(), Clients: &clients.Clients{}, ControllerContext: &controllercmd.ControllerContext{}}
+			},
+			catalogdImage: "registry.redhat.io/catalogd:latest",
+		},
+		{
+			name:          "builder not configured",
+			builder:       func() Builder { return Builder{} },
+			catalogdImage: "registry.redhat.io/catalogd:latest",
+			expectedErrors: []string{
+				"clients are not set",
+				"controller context is not set",
+				"assets are not set",
+			},
+		},
+		{
+			name: "missing and empty subdirectories",
+			builder: func() Builder {
+				assets := validAssets()
+				delete(assets, "catalogd/deployment.yaml")
+				delete(assets, "operator-controller/serviceaccount.yaml")
+				delete(assets, "operator-controller/nested/configmap.yaml")
+				return Builder{Assets: assets, Clients: &clients.Clients{}, ControllerContext: &controllercmd.ControllerContext{}}
+			},
+			expectedErrors: []string{
+				`assets of "catalogd": open catalogd: file does not exist`,
+				`assets of "operator-controller": no manifests found`,
+			},
+		},
+		{
+			name: "subdirectory is a file",
+			builder: func() Builder {
+				assets := validAssets()
+				delete(assets, "catalogd/deployment.yaml")
+				assets["catalogd"] = &fstest.MapFile{Data: deployment}
+				return Builder{Assets: assets, Clients: &clients.Clients{}, ControllerContext: &controllercmd.ControllerContext{}}
+			},
+			expectedErrors: []string{`assets of "catalogd": "catalogd" is not a directory`},
+		},
+		{
+			name: "image environment variable not set",
+			builder: func() Builder {
+				return Builder{Assets: validAssets(), Clients: &clients.Clients{}, ControllerContext: &controllercmd.ControllerContext{}}
+			},
+			expectedErrors: []string{`assets file "catalogd/deployment.yaml" uses ${CATALOGD_IMAGE} but the CATALOGD_IMAGE environment variable is not set`},
+		},
+		{
+			name: "only excluded manifests and an invalid pattern",
+			builder: func() Builder {
+				return Builder{
+					Assets:            validAssets(),
+					Clients:           &clients.Clients{},
+					ControllerContext: &controllercmd.ControllerContext{},
+					ExcludedManifests: []string{"catalogd/*", "["},
+				}
+			},
+			expectedErrors: []string{
+				`invalid excluded manifest pattern "["`,
+				`assets of "catalogd": no manifests found`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CATALOGD_IMAGE", tc.catalogdImage)
+			b := tc.builder()
+			err := b.Validate("catalogd", "operator-controller")
+			if len(tc.expectedErrors) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range tc.expectedErrors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %s, got: %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildControllersExcludedManifests(t *testing.T) {
+	b := Builder{
+		Assets: fstest.MapFS{
+			"operator-controller/05-deployment.yaml": &fstest.MapFile{Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  namespace: openshift-operator-controller
+  name: operator-controller-controller-manager
+`)},
+			// excluded files are not parsed
+			"operator-controller/06-optional-deployment.yaml": &fstest.MapFile{Data: []byte(`not a manifest`)},
+		},
+		ExcludedManifests: []string{"operator-controller/06-optional-*.yaml", "*/05-deployment.yaml"},
+	}
+	staticResourceControllers, deploymentControllers, clusterCatalogControllers, relatedObjects, err := b.BuildControllers("operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(staticResourceControllers) != 0 || len(deploymentControllers) != 0 || len(clusterCatalogControllers) != 0 {
+		t.Errorf("expected no controllers for the excluded manifests, got %v, %v and %v", staticResourceControllers, deploymentControllers, clusterCatalogControllers)
+	}
+	if len(relatedObjects) != 0 {
+		t.Errorf("expected the excluded manifests not to be related objects, got %v", relatedObjects)
+	}
+
+	b.ExcludedManifests = []string{"operator-controller/[.yaml"}
+	if _, _, _, _, err := b.BuildControllers("operator-controller"); err == nil || !strings.Contains(err.Error(), `invalid excluded manifest pattern "operator-controller/[.yaml"`) {
+		t.Fatalf("expected an invalid pattern error, got %v", err)
+	}
+}
+
+func TestUniqueObjectReferences(t *testing.T) {
+	shared := configv1.ObjectReference{Resource: "namespaces", Name: "openshift-olm"}
+	catalogd := []configv1.ObjectReference{
+		shared,
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+	}
+	operatorController := []configv1.ObjectReference{
+		shared,
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-operator-controller", Name: "operator-controller-controller-manager"},
+		// same name in another namespace is a different object
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-catalogd", Name: "operator-controller-controller-manager"},
+	}
+
+	actual := uniqueObjectReferences(append(append([]configv1.ObjectReference{}, catalogd...), operatorController...))
+	expected := []configv1.ObjectReference{
+		shared,
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-operator-controller", Name: "operator-controller-controller-manager"},
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-catalogd", Name: "operator-controller-controller-manager"},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected relatedObjects %v, got %v", expected, actual)
+	}
+}
+
+func TestOwnershipLabelsHookRestoresStrippedLabels(t *testing.T) {
+	ctx := context.Background()
+	rendered := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-catalogd", Name: "catalogd-controller-manager", Labels: map[string]string{"app": "catalogd"}},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"control-plane": "catalogd-controller-manager"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "manager", Image: "catalogd:latest"}}},
+				},
+			},
+		}
+	}
+
+	required := rendered()
+	if err := ownershipLabelsHook(nil, required); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(rendered().Spec.Template, required.Spec.Template) {
+		t.Fatalf("expected the pod template to be left alone, got %+v", required.Spec.Template)
+	}
+
+	// the ownership labels were stripped and an unrelated label added
+	existing := rendered()
+	existing.Labels = map[string]string{"app": "catalogd", "team": "infra"}
+	existing.Generation = 1
+	kubeClient := kubefake.NewSimpleClientset(existing)
+
+	actual, modified, err := resourceapply.ApplyDeployment(ctx, kubeClient.AppsV1(), events.NewInMemoryRecorder("test"), required, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !modified {
+		t.Fatal("expected the stripped labels to be restored")
+	}
+	expected := map[string]string{
+		"app":                         "catalogd",
+		"team":                        "infra",
+		"app.kubernetes.io/part-of":   "olm",
+		"olm.openshift.io/managed-by": "cluster-olm-operator",
+	}
+	if !reflect.DeepEqual(expected, actual.Labels) {
+		t.Errorf("expected labels %v, got %v", expected, actual.Labels)
+	}
+}
+
+func TestSortByApplyOrder(t *testing.T) {
+	kinds := map[string]schema.GroupVersionKind{
+		"catalogd/00-clusterrole.yaml":    {Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+		"catalogd/01-configmap.yaml":      {Version: "v1", Kind: "ConfigMap"},
+		"catalogd/02-serviceaccount.yaml": {Version: "v1", Kind: "ServiceAccount"},
+		"catalogd/03-namespace.yaml":      {Version: "v1", Kind: "Namespace"},
+		"catalogd/04-crd.yaml":            {Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+		"catalogd/05-other-crd.yaml":      {Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+		// a custom resource named like the built-in kinds is not a dependency
+		"catalogd/06-namespace-cr.yaml": {Group: "example.com", Version: "v1", Kind: "Namespace"},
+	}
+	files := []string{
+		"catalogd/00-clusterrole.yaml",
+		"catalogd/01-configmap.yaml",
+		"catalogd/02-serviceaccount.yaml",
+		"catalogd/03-namespace.yaml",
+		"catalogd/04-crd.yaml",
+		"catalogd/05-other-crd.yaml",
+		"catalogd/06-namespace-cr.yaml",
+	}
+	ranks := map[string]int{}
+	for file, gvk := range kinds {
+		ranks[file] = applyOrderRank(gvk)
+	}
+
+	sortByApplyOrder(files, ranks)
+
+	expected := []string{
+		"catalogd/04-crd.yaml",
+		"catalogd/05-other-crd.yaml",
+		"catalogd/03-namespace.yaml",
+		"catalogd/00-clusterrole.yaml",
+		"catalogd/01-configmap.yaml",
+		"catalogd/02-serviceaccount.yaml",
+		"catalogd/06-namespace-cr.yaml",
+	}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("expected files %v, got %v", expected, files)
+	}
+}
+
+func TestClusterCatalogImages(t *testing.T) {
+	b := Builder{Assets: fstest.MapFS{
+		"catalogd/clustercatalog.yaml": &fstest.MapFile{Data: []byte(`apiVersion: olm.operatorframework.io/v1
+kind: ClusterCatalog
+metadata:
+  name: openshift-redhat-operators
+spec:
+  source:
+    type: Image
+    image:
+      ref: registry.redhat.io/redhat/redhat-operator-index:v4.18
+`)},
+		"catalogd/serviceaccount.yaml": &fstest.MapFile{Data: []byte(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  namespace: openshift-catalogd
+  name: catalogd-controller-manager
+`)},
+	}}
+	images, err := b.ClusterCatalogImages("catalogd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"openshift-redhat-operators": "registry.redhat.io/redhat/redhat-operator-index:v4.18"}
+	if !reflect.DeepEqual(expected, images) {
+		t.Errorf("expected images %v, got %v", expected, images)
+	}
+	b.ExcludedManifests = []string{"catalogd/clustercatalog.yaml"}
+	images, err = b.ClusterCatalogImages("catalogd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(images) != 0 {
+		t.Errorf("expected no images for excluded ClusterCatalogs, got %v", images)
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	typeClusterCatalogImagePolicyDegraded = "ClusterCatalogImagePolicyDegraded"
+	reasonClusterCatalogRegistryBlocked   = "ClusterCatalogRegistryBlocked"
+)
+
+// NewClusterCatalogImagePolicyController returns a controller reporting a degraded
+// condition when the image of a managed ClusterCatalog is hosted on a registry the
+// cluster Image config does not allow, so that catalogd would fail to pull it.
+// catalogImages maps the managed ClusterCatalog names to their image references.
+func NewClusterCatalogImagePolicyController(name string, catalogImages map[string]string, imageInformer configv1informers.ImageInformer, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &clusterCatalogImagePolicyController{
+		name:           name,
+		catalogImages:  catalogImages,
+		imageLister:    imageInformer.Lister(),
+		operatorClient: operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), imageInformer.Informer()).ToController(name, eventRecorder)
+}
+
+type clusterCatalogImagePolicyController struct {
+	name           string
+	catalogImages  map[string]string
+	imageLister    configv1listers.ImageLister
+	operatorClient v1helpers.OperatorClient
+}
+
+func (c *clusterCatalogImagePolicyController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	imageConfig, err := c.imageLister.Get("cluster")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error getting images.config.openshift.io/cluster: %w", err)
+	}
+
+	var blocked []string
+	if imageConfig != nil {
+		for name, image := range c.catalogImages {
+			if !imageAllowed(image, imageConfig.Spec.RegistrySources) {
+				blocked = append(blocked, fmt.Sprintf("%s (%s)", name, image))
+			}
+		}
+	}
+	sort.Strings(blocked)
+
+	cond := operatorv1.OperatorCondition{
+		Type:   typeClusterCatalogImagePolicyDegraded,
+		Status: operatorv1.ConditionFalse,
+		Reason: reasonAsExpected,
+	}
+	if len(blocked) > 0 {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = reasonClusterCatalogRegistryBlocked
+		cond.Message = fmt.Sprintf("the images of ClusterCatalogs %s are hosted on registries not allowed by images.config.openshift.io/cluster and cannot be pulled", strings.Join(blocked, ", "))
+	}
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
+
+// imageAllowed returns whether the image may be pulled under the registry sources. When
+// allowed registries are set, only images of those registries are allowed; otherwise
+// images of the blocked registries are not.
+func imageAllowed(image string, sources configv1.RegistrySources) bool {
+	repository := imageRepository(image)
+	if len(sources.AllowedRegistries) > 0 {
+		for _, registry := range sources.AllowedRegistries {
+			if registryMatches(repository, registry) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, registry := range sources.BlockedRegistries {
+		if registryMatches(repository, registry) {
+			return false
+		}
+	}
+	return true
+}
+
+// imageRepository returns the image reference without its tag or digest, qualified
+// with docker.io when it has no registry host.
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	host, _, found := strings.Cut(image, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		image = "docker.io/" + image
+	}
+	return image
+}
+
+// registryMatches returns whether the repository belongs to the registry, which is a
+// host optionally followed by a repository path, or a wildcard such as *.example.com
+// matching the subdomains of that host.
+func registryMatches(repository, registry string) bool {
+	if suffix, ok := strings.CutPrefix(registry, "*."); ok {
+		host, _, _ := strings.Cut(repository, "/")
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return repository == registry || strings.HasPrefix(repository, registry+"/")
+}
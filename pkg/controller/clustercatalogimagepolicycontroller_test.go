@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestClusterCatalogImagePolicyControllerSync(t *testing.T) {
+	catalogImages := map[string]string{
+		"openshift-redhat-operators":    "registry.redhat.io/redhat/redhat-operator-index:v4.18",
+		"openshift-community-operators": "registry.redhat.io/redhat/community-operator-index@sha256:0123",
+	}
+	for _, tc := range []struct {
+		name            string
+		sources         *configv1.RegistrySources
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "no image config",
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name:           "catalog registry allowed",
+			sources:        &configv1.RegistrySources{AllowedRegistries: []string{"quay.io", "registry.redhat.io"}},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name:           "other registry blocked",
+			sources:        &configv1.RegistrySources{BlockedRegistries: []string{"docker.io", "*.example.com"}},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name:            "catalog registry blocked",
+			sources:         &configv1.RegistrySources{BlockedRegistries: []string{"registry.redhat.io"}},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonClusterCatalogRegistryBlocked,
+			expectedMessage: "the images of ClusterCatalogs openshift-community-operators (registry.redhat.io/redhat/community-operator-index@sha256:0123), openshift-redhat-operators (registry.redhat.io/redhat/redhat-operator-index:v4.18) are hosted on registries not allowed by images.config.openshift.io/cluster and cannot be pulled",
+		},
+		{
+			name:            "catalog repository not allowed",
+			sources:         &configv1.RegistrySources{AllowedRegistries: []string{"registry.redhat.io/redhat/redhat-operator-index"}},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonClusterCatalogRegistryBlocked,
+			expectedMessage: "the images of ClusterCatalogs openshift-community-operators (registry.redhat.io/redhat/community-operator-index@sha256:0123) are hosted on registries not allowed by images.config.openshift.io/cluster and cannot be pulled",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.sources != nil {
+				if err := indexer.Add(&configv1.Image{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+					Spec:       configv1.ImageSpec{RegistrySources: *tc.sources},
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &clusterCatalogImagePolicyController{
+				name:           "test",
+				catalogImages:  catalogImages,
+				imageLister:    configv1listers.NewImageLister(indexer),
+				operatorClient: operatorClient,
+			}
+
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeClusterCatalogImagePolicyDegraded, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
+
+func TestImageAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		image    string
+		sources  configv1.RegistrySources
+		expected bool
+	}{
+		{image: "quay.io/org/index:latest", sources: configv1.RegistrySources{}, expected: true},
+		{image: "quay.io/org/index:latest", sources: configv1.RegistrySources{BlockedRegistries: []string{"quay.io"}}, expected: false},
+		{image: "quay.io.example.com/org/index", sources: configv1.RegistrySources{BlockedRegistries: []string{"quay.io"}}, expected: true},
+		{image: "mirror.example.com:5000/org/index:latest", sources: configv1.RegistrySources{BlockedRegistries: []string{"mirror.example.com:5000"}}, expected: false},
+		{image: "a.mirror.example.com/org/index", sources: configv1.RegistrySources{AllowedRegistries: []string{"*.example.com"}}, expected: true},
+		{image: "example.com/org/index", sources: configv1.RegistrySources{AllowedRegistries: []string{"*.example.com"}}, expected: false},
+		{image: "library/busybox", sources: configv1.RegistrySources{BlockedRegistries: []string{"docker.io"}}, expected: false},
+		// allowed registries take precedence over blocked ones
+		{image: "quay.io/org/index", sources: configv1.RegistrySources{AllowedRegistries: []string{"quay.io"}, BlockedRegistries: []string{"quay.io"}}, expected: true},
+	} {
+		if actual := imageAllowed(tc.image, tc.sources); actual != tc.expected {
+			t.Errorf("expected imageAllowed(%q, %+v) to be %v, got %v", tc.image, tc.sources, tc.expected, actual)
+		}
+	}
+}
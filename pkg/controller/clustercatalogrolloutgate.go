@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	typeClusterCatalogRolloutGateProgressing = "ClusterCatalogRolloutGateProgressing"
+	reasonWaitingForClusterCatalogs          = "WaitingForClusterCatalogs"
+	reasonClusterCatalogsReady               = "ClusterCatalogsReady"
+	reasonClusterCatalogWaitTimedOut         = "ClusterCatalogWaitTimedOut"
+
+	clusterCatalogRolloutGatePollInterval = 5 * time.Second
+)
+
+// clusterCatalogRolloutGate holds the controllers it gates until all the managed
+// ClusterCatalogs are serving, or until the timeout elapses, whichever comes first.
+// The wait happens once and is shared by all the gated controllers.
+type clusterCatalogRolloutGate struct {
+	name           string
+	catalogNames   []string
+	timeout        time.Duration
+	pollInterval   time.Duration
+	objectGetFunc  getObjectFunc
+	operatorClient v1helpers.OperatorClient
+
+	once sync.Once
+}
+
+// newClusterCatalogRolloutGate returns a gate waiting for the named ClusterCatalogs
+// to serve for at most timeout.
+func newClusterCatalogRolloutGate(name string, catalogNames []string, timeout time.Duration, clusterCatalogClient *clients.ClusterCatalogClient, operatorClient *clients.OperatorClient) *clusterCatalogRolloutGate {
+	return &clusterCatalogRolloutGate{
+		name:           name,
+		catalogNames:   catalogNames,
+		timeout:        timeout,
+		pollInterval:   clusterCatalogRolloutGatePollInterval,
+		objectGetFunc:  clusterCatalogClient.Get,
+		operatorClient: operatorClient,
+	}
+}
+
+// gate returns c with its Run held until the gate is released.
+func (g *clusterCatalogRolloutGate) gate(c factory.Controller) factory.Controller {
+	return &gatedController{Controller: c, gate: g}
+}
+
+type gatedController struct {
+	factory.Controller
+	gate *clusterCatalogRolloutGate
+}
+
+func (c *gatedController) Run(ctx context.Context, workers int) {
+	c.gate.once.Do(func() { c.gate.wait(ctx) })
+	if ctx.Err() != nil {
+		return
+	}
+	c.Controller.Run(ctx, workers)
+}
+
+func (g *clusterCatalogRolloutGate) wait(ctx context.Context) {
+	logger := klog.FromContext(ctx).WithName(g.name)
+
+	var notServing []string
+	err := wait.PollUntilContextTimeout(ctx, g.pollInterval, g.timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		notServing, err = g.notServing()
+		if err != nil {
+			// transient lookup failures should not release the gate early
+			logger.Error(err, "failed to evaluate the managed ClusterCatalogs")
+			return false, nil
+		}
+		if len(notServing) == 0 {
+			return true, nil
+		}
+		g.updateCondition(ctx, operatorv1.OperatorCondition{
+			Type:    typeClusterCatalogRolloutGateProgressing,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  reasonWaitingForClusterCatalogs,
+			Message: fmt.Sprintf("waiting for ClusterCatalogs %s to serve before rolling out operands", strings.Join(notServing, ",")),
+		})
+		return false, nil
+	})
+	switch {
+	case err == nil:
+		logger.Info("managed ClusterCatalogs are serving, releasing the operand rollout")
+		g.updateCondition(ctx, operatorv1.OperatorCondition{
+			Type:    typeClusterCatalogRolloutGateProgressing,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  reasonClusterCatalogsReady,
+			Message: "managed ClusterCatalogs are serving",
+		})
+	case ctx.Err() == nil:
+		logger.Info("timed out waiting for the managed ClusterCatalogs, releasing the operand rollout", "timeout", g.timeout, "notServing", notServing)
+		g.updateCondition(ctx, operatorv1.OperatorCondition{
+			Type:    typeClusterCatalogRolloutGateProgressing,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  reasonClusterCatalogWaitTimedOut,
+			Message: fmt.Sprintf("timed out after %s waiting for ClusterCatalogs %s to serve; operands were rolled out anyway", g.timeout, strings.Join(notServing, ",")),
+		})
+	}
+}
+
+func (g *clusterCatalogRolloutGate) notServing() ([]string, error) {
+	var notServing []string
+	for _, name := range g.catalogNames {
+		obj, err := g.objectGetFunc(types.NamespacedName{Name: name})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("fetching ClusterCatalog %q: %w", name, err)
+		}
+		serving, err := isClusterCatalogServing(obj)
+		if err != nil {
+			return nil, fmt.Errorf("evaluating ClusterCatalog %q: %w", name, err)
+		}
+		if !serving {
+			notServing = append(notServing, name)
+		}
+	}
+	return notServing, nil
+}
+
+func (g *clusterCatalogRolloutGate) updateCondition(ctx context.Context, cond operatorv1.OperatorCondition) {
+	if _, _, err := v1helpers.UpdateStatus(ctx, g.operatorClient, v1helpers.UpdateConditionFn(cond)); err != nil {
+		klog.FromContext(ctx).WithName(g.name).Error(err, "failed to update the rollout gate condition")
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	catalogdv1 "github.com/operator-framework/catalogd/api/v1"
+)
+
+// runRecorder is a controller signalling when it is run.
+type runRecorder struct {
+	factory.Controller
+	ran chan struct{}
+}
+
+func (c *runRecorder) Run(context.Context, int) {
+	close(c.ran)
+}
+
+func TestClusterCatalogRolloutGateHoldAndRelease(t *testing.T) {
+	var serving atomic.Bool
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	gate := &clusterCatalogRolloutGate{
+		name:         "test",
+		catalogNames: []string{"a"},
+		timeout:      time.Minute,
+		pollInterval: 10 * time.Millisecond,
+		objectGetFunc: func(key types.NamespacedName) (runtime.Object, error) {
+			if !serving.Load() {
+				return nil, apierrors.NewNotFound(catalogdv1.GroupVersion.WithResource("clustercatalogs").GroupResource(), key.Name)
+			}
+			return clusterCatalogWithServing(t, key.Name, metav1.ConditionTrue), nil
+		},
+		operatorClient: operatorClient,
+	}
+	inner := &runRecorder{ran: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go gate.gate(inner).Run(ctx, 1)
+
+	select {
+	case <-inner.ran:
+		t.Fatal("expected the controller to be held while the ClusterCatalogs are not serving")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assertOperatorCondition(t, operatorClient, typeClusterCatalogRolloutGateProgressing, operatorv1.ConditionTrue, reasonWaitingForClusterCatalogs, "waiting for ClusterCatalogs a to serve before rolling out operands")
+
+	serving.Store(true)
+	select {
+	case <-inner.ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the controller to be released once the ClusterCatalogs are serving")
+	}
+	assertOperatorCondition(t, operatorClient, typeClusterCatalogRolloutGateProgressing, operatorv1.ConditionFalse, reasonClusterCatalogsReady, "managed ClusterCatalogs are serving")
+}
+
+func TestClusterCatalogRolloutGateTimeout(t *testing.T) {
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	gate := &clusterCatalogRolloutGate{
+		name:         "test",
+		catalogNames: []string{"a", "b"},
+		timeout:      50 * time.Millisecond,
+		pollInterval: 10 * time.Millisecond,
+		objectGetFunc: func(key types.NamespacedName) (runtime.Object, error) {
+			return nil, apierrors.NewNotFound(catalogdv1.GroupVersion.WithResource("clustercatalogs").GroupResource(), key.Name)
+		},
+		operatorClient: operatorClient,
+	}
+	first := &runRecorder{ran: make(chan struct{})}
+	second := &runRecorder{ran: make(chan struct{})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go gate.gate(first).Run(ctx, 1)
+	go gate.gate(second).Run(ctx, 1)
+
+	for _, c := range []*runRecorder{first, second} {
+		select {
+		case <-c.ran:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the controllers to be released once the timeout elapsed")
+		}
+	}
+	assertOperatorCondition(t, operatorClient, typeClusterCatalogRolloutGateProgressing, operatorv1.ConditionFalse, reasonClusterCatalogWaitTimedOut, "timed out after 50ms waiting for ClusterCatalogs a,b to serve; operands were rolled out anyway")
+}
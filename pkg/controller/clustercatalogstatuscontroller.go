@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+
+	catalogdv1 "github.com/operator-framework/catalogd/api/v1"
+)
+
+const (
+	typeClusterCatalogsServing      = "ClusterCatalogsServing"
+	reasonAllClusterCatalogsServing = "AllClusterCatalogsServing"
+	reasonClusterCatalogsNotServing = "ClusterCatalogsNotServing"
+)
+
+// NewClusterCatalogStatusController returns a controller that maintains an informational
+// condition summarizing how many of the managed ClusterCatalogs are serving.
+func NewClusterCatalogStatusController(name string, catalogNames []string, clusterCatalogClient *clients.ClusterCatalogClient, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &clusterCatalogStatusController{
+		name:           name,
+		catalogNames:   catalogNames,
+		objectGetFunc:  clusterCatalogClient.Get,
+		operatorClient: operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), clusterCatalogClient.Informer()).ToController(name, eventRecorder)
+}
+
+type clusterCatalogStatusController struct {
+	name           string
+	catalogNames   []string
+	objectGetFunc  getObjectFunc
+	operatorClient v1helpers.OperatorClient
+}
+
+func (c *clusterCatalogStatusController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	var notServing []string
+	for _, name := range c.catalogNames {
+		obj, err := c.objectGetFunc(types.NamespacedName{Name: name})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("fetching ClusterCatalog %q: %w", name, err)
+		}
+		serving, err := isClusterCatalogServing(obj)
+		if err != nil {
+			return fmt.Errorf("evaluating ClusterCatalog %q: %w", name, err)
+		}
+		if !serving {
+			notServing = append(notServing, name)
+		}
+	}
+
+	cond := clusterCatalogsServingCondition(len(c.catalogNames), notServing)
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
+
+// isClusterCatalogServing returns true if the provided ClusterCatalog reports
+// a Serving condition with status True. A nil object is treated as not serving.
+func isClusterCatalogServing(obj runtime.Object) (bool, error) {
+	if obj == nil {
+		return false, nil
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("expected object to be of type *unstructured.Unstructured but was %T", obj)
+	}
+	var catalog catalogdv1.ClusterCatalog
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &catalog); err != nil {
+		return false, fmt.Errorf("converting from unstructured: %w", err)
+	}
+	return meta.IsStatusConditionTrue(catalog.Status.Conditions, catalogdv1.TypeServing), nil
+}
+
+func clusterCatalogsServingCondition(total int, notServing []string) operatorv1.OperatorCondition {
+	serving := total - len(notServing)
+	message := fmt.Sprintf("%d of %d managed ClusterCatalogs are serving", serving, total)
+	if len(notServing) > 0 {
+		return operatorv1.OperatorCondition{
+			Type:    typeClusterCatalogsServing,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  reasonClusterCatalogsNotServing,
+			Message: fmt.Sprintf("%s; not serving: %s", message, strings.Join(notServing, ",")),
+		}
+	}
+	return operatorv1.OperatorCondition{
+		Type:    typeClusterCatalogsServing,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  reasonAllClusterCatalogsServing,
+		Message: message,
+	}
+}
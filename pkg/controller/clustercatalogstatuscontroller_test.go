@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	catalogdv1 "github.com/operator-framework/catalogd/api/v1"
+)
+
+func clusterCatalogWithServing(t *testing.T, name string, status metav1.ConditionStatus) *unstructured.Unstructured {
+	t.Helper()
+	catalog := &catalogdv1.ClusterCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: catalogdv1.ClusterCatalogStatus{
+			Conditions: []metav1.Condition{
+				{Type: catalogdv1.TypeServing, Status: status},
+			},
+		},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(catalog)
+	if err != nil {
+		t.Fatalf("converting ClusterCatalog to unstructured: %v", err)
+	}
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func TestClusterCatalogStatusControllerSync(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		catalogs        map[string]runtime.Object
+		catalogNames    []string
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:         "all catalogs serving",
+			catalogNames: []string{"a", "b"},
+			catalogs: map[string]runtime.Object{
+				"a": clusterCatalogWithServing(t, "a", metav1.ConditionTrue),
+				"b": clusterCatalogWithServing(t, "b", metav1.ConditionTrue),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonAllClusterCatalogsServing,
+			expectedMessage: "2 of 2 managed ClusterCatalogs are serving",
+		},
+		{
+			name:         "some catalogs not serving",
+			catalogNames: []string{"a", "b", "c"},
+			catalogs: map[string]runtime.Object{
+				"a": clusterCatalogWithServing(t, "a", metav1.ConditionTrue),
+				"b": clusterCatalogWithServing(t, "b", metav1.ConditionFalse),
+				"c": clusterCatalogWithServing(t, "c", metav1.ConditionTrue),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  reasonClusterCatalogsNotServing,
+			expectedMessage: "2 of 3 managed ClusterCatalogs are serving; not serving: b",
+		},
+		{
+			name:         "missing catalog is not serving",
+			catalogNames: []string{"a", "b"},
+			catalogs: map[string]runtime.Object{
+				"a": clusterCatalogWithServing(t, "a", metav1.ConditionTrue),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  reasonClusterCatalogsNotServing,
+			expectedMessage: "1 of 2 managed ClusterCatalogs are serving; not serving: b",
+		},
+		{
+			name:         "catalog without conditions is not serving",
+			catalogNames: []string{"a"},
+			catalogs: map[string]runtime.Object{
+				"a": &unstructured.Unstructured{Object: map[string]interface{}{}},
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  reasonClusterCatalogsNotServing,
+			expectedMessage: "0 of 1 managed ClusterCatalogs are serving; not serving: a",
+		},
+		{
+			name:            "no managed catalogs",
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonAllClusterCatalogsServing,
+			expectedMessage: "0 of 0 managed ClusterCatalogs are serving",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &clusterCatalogStatusController{
+				name:         "test",
+				catalogNames: tc.catalogNames,
+				objectGetFunc: func(key types.NamespacedName) (runtime.Object, error) {
+					obj, ok := tc.catalogs[key.Name]
+					if !ok {
+						return nil, apierrors.NewNotFound(catalogdv1.GroupVersion.WithResource("clustercatalogs").GroupResource(), key.Name)
+					}
+					return obj, nil
+				},
+				operatorClient: operatorClient,
+			}
+
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeClusterCatalogsServing, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
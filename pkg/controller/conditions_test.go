@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// assertOperatorCondition checks the status, reason and message of the condition the
+// operator client reports for conditionType.
+func assertOperatorCondition(t *testing.T, operatorClient v1helpers.OperatorClient, conditionType string, status operatorv1.ConditionStatus, reason, message string) {
+	t.Helper()
+	_, operatorStatus, _, err := operatorClient.GetOperatorState()
+	if err != nil {
+		t.Fatalf("unexpected error getting operator state: %v", err)
+	}
+	cond := v1helpers.FindOperatorCondition(operatorStatus.Conditions, conditionType)
+	if cond == nil {
+		t.Fatalf("expected condition %q to be set", conditionType)
+	}
+	if cond.Status != status || cond.Reason != reason || cond.Message != message {
+		t.Errorf("expected condition %q status %q, reason %q and message %q, got %q, %q and %q", conditionType, status, reason, message, cond.Status, cond.Reason, cond.Message)
+	}
+}
@@ -0,0 +1,89 @@
+// Package controllertesting provides fakes for testing controllers built on the
+// interfaces of the controller package.
+package controllertesting
+
+import (
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	fcache "k8s.io/client-go/tools/cache/testing"
+
+	"github.com/openshift/cluster-olm-operator/pkg/controller"
+)
+
+var _ controller.ResourceClient = &FakeResourceClient{}
+
+// FakeResourceClient is an in-memory controller.ResourceClient. Objects are kept in a
+// map served by Get, and mirrored to a fake source backing the informer, so that
+// running the informer delivers the same objects as events.
+type FakeResourceClient struct {
+	lock     sync.RWMutex
+	resource schema.GroupResource
+	objects  map[types.NamespacedName]*unstructured.Unstructured
+	source   *fcache.FakeControllerSource
+	informer cache.SharedIndexInformer
+}
+
+// NewFakeResourceClient returns a FakeResourceClient for the given resource, holding
+// the given objects.
+func NewFakeResourceClient(resource schema.GroupResource, objects ...*unstructured.Unstructured) *FakeResourceClient {
+	source := fcache.NewFakeControllerSource()
+	f := &FakeResourceClient{
+		resource: resource,
+		objects:  map[types.NamespacedName]*unstructured.Unstructured{},
+		source:   source,
+		informer: cache.NewSharedIndexInformer(source, &unstructured.Unstructured{}, 0, cache.Indexers{}),
+	}
+	for _, obj := range objects {
+		f.Set(obj)
+	}
+	return f
+}
+
+// Get returns a copy of the object stored under key, or a NotFound error.
+func (f *FakeResourceClient) Get(key types.NamespacedName) (runtime.Object, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	obj, ok := f.objects[key]
+	if !ok {
+		return nil, apierrors.NewNotFound(f.resource, key.Name)
+	}
+	return obj.DeepCopy(), nil
+}
+
+// Informer returns an informer over the stored objects. It is not started; callers
+// that need its cache or event handlers must run it.
+func (f *FakeResourceClient) Informer() cache.SharedIndexInformer {
+	return f.informer
+}
+
+// Set adds or replaces an object.
+func (f *FakeResourceClient) Set(obj *unstructured.Unstructured) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	_, exists := f.objects[key]
+	f.objects[key] = obj.DeepCopy()
+	if exists {
+		f.source.Modify(obj.DeepCopy())
+	} else {
+		f.source.Add(obj.DeepCopy())
+	}
+}
+
+// Delete removes the object stored under key, if any.
+func (f *FakeResourceClient) Delete(key types.NamespacedName) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	obj, ok := f.objects[key]
+	if !ok {
+		return
+	}
+	delete(f.objects, key)
+	f.source.Delete(obj.DeepCopy())
+}
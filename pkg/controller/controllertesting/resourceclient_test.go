@@ -0,0 +1,76 @@
+package controllertesting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+)
+
+func clusterCatalog(name, source string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("olm.operatorframework.io/v1")
+	obj.SetKind("ClusterCatalog")
+	obj.SetName(name)
+	_ = unstructured.SetNestedField(obj.Object, source, "spec", "source", "image", "ref")
+	return obj
+}
+
+func TestFakeResourceClientGet(t *testing.T) {
+	resource := schema.GroupResource{Group: "olm.operatorframework.io", Resource: "clustercatalogs"}
+	client := NewFakeResourceClient(resource, clusterCatalog("foo", "registry/foo:v1"))
+
+	obj, err := client.Get(types.NamespacedName{Name: "foo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ref, _, _ := unstructured.NestedString(obj.(*unstructured.Unstructured).Object, "spec", "source", "image", "ref")
+	if ref != "registry/foo:v1" {
+		t.Errorf("expected ref %q, got %q", "registry/foo:v1", ref)
+	}
+
+	// Mutating the returned object must not affect the stored one.
+	obj.(*unstructured.Unstructured).SetName("bar")
+	if _, err := client.Get(types.NamespacedName{Name: "foo"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client.Delete(types.NamespacedName{Name: "foo"})
+	_, err = client.Get(types.NamespacedName{Name: "foo"})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestFakeResourceClientInformer(t *testing.T) {
+	resource := schema.GroupResource{Group: "olm.operatorframework.io", Resource: "clustercatalogs"}
+	client := NewFakeResourceClient(resource, clusterCatalog("foo", "registry/foo:v1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informer := client.Informer()
+	go informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		t.Fatal("informer did not sync")
+	}
+	if _, exists, err := informer.GetStore().GetByKey("foo"); err != nil || !exists {
+		t.Fatalf("expected foo in the informer cache, exists=%v err=%v", exists, err)
+	}
+
+	client.Set(clusterCatalog("bar", "registry/bar:v1"))
+	client.Delete(types.NamespacedName{Name: "foo"})
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		_, fooExists, _ := informer.GetStore().GetByKey("foo")
+		_, barExists, _ := informer.GetStore().GetByKey("bar")
+		return !fooExists && barExists, nil
+	})
+	if err != nil {
+		t.Fatalf("informer cache did not observe the changes: %v", err)
+	}
+}
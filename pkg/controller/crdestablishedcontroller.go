@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	typeOperandCRDsEstablished      = "OperandCRDsEstablished"
+	reasonAllOperandCRDsEstablished = "AllOperandCRDsEstablished"
+	reasonOperandCRDsNotEstablished = "OperandCRDsNotEstablished"
+
+	crdEstablishedResyncInterval = 30 * time.Second
+)
+
+// NewCRDEstablishedController returns a controller that maintains an informational
+// condition reporting whether the given operand CRDs are established.
+func NewCRDEstablishedController(name string, crdNames []string, apiExtensionsClient apiextensionsclient.Interface, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &crdEstablishedController{
+		name:                name,
+		crdNames:            crdNames,
+		apiExtensionsClient: apiExtensionsClient,
+		operatorClient:      operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer()).ResyncEvery(crdEstablishedResyncInterval).ToController(name, eventRecorder)
+}
+
+type crdEstablishedController struct {
+	name                string
+	crdNames            []string
+	apiExtensionsClient apiextensionsclient.Interface
+	operatorClient      v1helpers.OperatorClient
+}
+
+func (c *crdEstablishedController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	var notEstablished []string
+	for _, name := range c.crdNames {
+		crd, err := c.apiExtensionsClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("fetching CustomResourceDefinition %q: %w", name, err)
+		}
+		if err != nil || !isCRDEstablished(crd) {
+			notEstablished = append(notEstablished, name)
+		}
+	}
+
+	cond := operatorv1.OperatorCondition{
+		Type:    typeOperandCRDsEstablished,
+		Status:  operatorv1.ConditionTrue,
+		Reason:  reasonAllOperandCRDsEstablished,
+		Message: fmt.Sprintf("%d of %d operand CRDs are established", len(c.crdNames), len(c.crdNames)),
+	}
+	if len(notEstablished) > 0 {
+		cond.Status = operatorv1.ConditionFalse
+		cond.Reason = reasonOperandCRDsNotEstablished
+		cond.Message = fmt.Sprintf("%d of %d operand CRDs are established; not established: %s", len(c.crdNames)-len(notEstablished), len(c.crdNames), strings.Join(notEstablished, ","))
+	}
+
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
+
+func isCRDEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, cond := range crd.Status.Conditions {
+		if cond.Type == apiextensionsv1.Established {
+			return cond.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
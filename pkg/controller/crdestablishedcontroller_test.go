@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func crdWithEstablished(name string, status apiextensionsv1.ConditionStatus) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.Established, Status: status},
+			},
+		},
+	}
+}
+
+func TestCRDEstablishedControllerSync(t *testing.T) {
+	crdNames := []string{"clustercatalogs.olm.operatorframework.io", "clusterextensions.olm.operatorframework.io"}
+	for _, tc := range []struct {
+		name            string
+		crds            []runtime.Object
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name: "all established",
+			crds: []runtime.Object{
+				crdWithEstablished(crdNames[0], apiextensionsv1.ConditionTrue),
+				crdWithEstablished(crdNames[1], apiextensionsv1.ConditionTrue),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonAllOperandCRDsEstablished,
+			expectedMessage: "2 of 2 operand CRDs are established",
+		},
+		{
+			name: "not established",
+			crds: []runtime.Object{
+				crdWithEstablished(crdNames[0], apiextensionsv1.ConditionTrue),
+				crdWithEstablished(crdNames[1], apiextensionsv1.ConditionFalse),
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  reasonOperandCRDsNotEstablished,
+			expectedMessage: "1 of 2 operand CRDs are established; not established: clusterextensions.olm.operatorframework.io",
+		},
+		{
+			name: "missing and without conditions",
+			crds: []runtime.Object{
+				&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: crdNames[0]}},
+			},
+			expectedStatus:  operatorv1.ConditionFalse,
+			expectedReason:  reasonOperandCRDsNotEstablished,
+			expectedMessage: "0 of 2 operand CRDs are established; not established: clustercatalogs.olm.operatorframework.io,clusterextensions.olm.operatorframework.io",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &crdEstablishedController{
+				name:                "test",
+				crdNames:            crdNames,
+				apiExtensionsClient: apiextensionsfake.NewSimpleClientset(tc.crds...),
+				operatorClient:      operatorClient,
+			}
+
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeOperandCRDsEstablished, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
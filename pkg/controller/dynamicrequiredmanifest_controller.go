@@ -1,17 +1,22 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/client-go/config/clientset/versioned/scheme"
+	operatorv1apply "github.com/openshift/client-go/operator/applyconfigurations/operator/v1"
 	"github.com/openshift/cluster-olm-operator/pkg/clients"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	"golang.org/x/net/context"
 	"k8s.io/apimachinery/pkg/api/equality"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,35 +25,60 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	"k8s.io/utils/ptr"
 )
 
+const (
+	reasonClusterCatalogCRDNotReady = "ClusterCatalogCRDNotReady"
+	reasonClusterCatalogApplyFailed = "ClusterCatalogApplyFailed"
+	reasonSyncError                 = "SyncError"
+	reasonAsExpected                = "AsExpected"
+	reasonRepeatedExternalDeletion  = "RepeatedExternalDeletion"
+
+	// a managed resource re-created this many times within the window is reported
+	// as being deleted by another client
+	recreationThreshold = 3
+	recreationWindow    = 10 * time.Minute
+)
+
 type ResourceClient interface {
 	Get(types.NamespacedName) (runtime.Object, error)
 	Informer() cache.SharedIndexInformer
 }
 
-func NewDynamicRequiredManifestController(name string, manifest []byte, key types.NamespacedName, gvr schema.GroupVersionResource, operatorClient *clients.OperatorClient, dynamicClient dynamic.Interface, resourceClient ResourceClient, recorder events.Recorder) factory.Controller {
+// NewDynamicRequiredManifestController returns a controller enforcing the given manifest.
+// When reportOnly is set, drift of an existing resource from the manifest is only
+// reported through an event, once each time the resource starts drifting, and never
+// reverted.
+func NewDynamicRequiredManifestController(name string, manifest []byte, key types.NamespacedName, gvr schema.GroupVersionResource, reportOnly bool, operatorClient *clients.OperatorClient, dynamicClient dynamic.Interface, resourceClient ResourceClient, recorder events.Recorder, slowSyncThreshold time.Duration, applyLogVerbosity int) factory.Controller {
 	c := &dynamicRequiredManifestController{
 		manifest:         manifest,
+		reportOnly:       reportOnly,
 		name:             name,
 		key:              key,
 		gvr:              gvr,
-		applyFunc:        defaultApplyFunc(dynamicClient),
+		applyFunc:        defaultApplyFunc(dynamicClient, applyLogVerbosity),
 		managedFunc:      defaultManagedFunc(operatorClient),
 		shouldUpdateFunc: unstructuredShouldUpdateFunc(),
 		objectGetFunc:    resourceClient.Get,
+		operatorClient:   operatorClient,
+		recreations:      newRecreationTracker(recreationThreshold, recreationWindow, clock.RealClock{}),
 	}
 
-	return factory.New().WithSync(c.sync).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), resourceClient.Informer()).ToController(c.name, recorder)
+	// The degraded condition is reported by the controller itself rather than through
+	// WithSyncDegradedOnError so that the reason can distinguish a missing CRD from
+	// a failed apply.
+	return factory.New().WithSync(instrumentSync(c.name, slowSyncThreshold, c.syncAndReportDegraded)).WithInformers(operatorClient.Informer(), resourceClient.Informer()).ToController(c.name, recorder)
 }
 
-func defaultApplyFunc(client dynamic.Interface) applyFunc {
+func defaultApplyFunc(client dynamic.Interface, applyLogVerbosity int) applyFunc {
 	return func(ctx context.Context, key types.NamespacedName, fieldManager string, force bool, gvr schema.GroupVersionResource, manifest []byte) error {
 		var resourceInterface dynamic.ResourceInterface = client.Resource(gvr)
 		if key.Namespace != "" {
 			resourceInterface = client.Resource(gvr).Namespace(key.Namespace)
 		}
+		logApply(klog.FromContext(ctx), applyLogVerbosity, gvr.String(), key.Namespace, key.Name, fieldManager, manifest)
 		_, err := resourceInterface.Patch(
 			ctx,
 			key.Name,
@@ -132,13 +162,129 @@ type dynamicRequiredManifestController struct {
 	key              types.NamespacedName
 	gvr              schema.GroupVersionResource
 	manifest         []byte
+	reportOnly       bool
 	applyFunc        applyFunc
 	managedFunc      managedFunc
 	shouldUpdateFunc shouldUpdateFunc
 	objectGetFunc    getObjectFunc
+	operatorClient   v1helpers.OperatorClient
+	// recreations, when set, tracks how often the resource is re-created after
+	// having been deleted.
+	recreations *recreationTracker
+	// drifted records whether the resource differed from the manifest on the last
+	// report-only sync, so the drift is reported only when it starts.
+	drifted bool
+}
+
+// recreationTracker counts the re-creations of a resource within a sliding window.
+type recreationTracker struct {
+	threshold int
+	window    time.Duration
+	clock     clock.PassiveClock
+
+	existed     bool
+	recreations []time.Time
+}
+
+func newRecreationTracker(threshold int, window time.Duration, clock clock.PassiveClock) *recreationTracker {
+	return &recreationTracker{threshold: threshold, window: window, clock: clock}
+}
+
+// observe records whether the resource exists, counting a re-creation when a
+// resource seen before is missing, and returns the re-creations within the window.
+func (t *recreationTracker) observe(exists bool) int {
+	if !exists && t.existed {
+		t.recreations = append(t.recreations, t.clock.Now())
+	}
+	t.existed = exists
+	return t.count()
+}
+
+// forget drops whether the resource was seen, so that it missing on the next
+// observation is not counted as a re-creation.
+func (t *recreationTracker) forget() {
+	t.existed = false
+}
+
+// count returns the re-creations within the window.
+func (t *recreationTracker) count() int {
+	now := t.clock.Now()
+	for len(t.recreations) > 0 && now.Sub(t.recreations[0]) > t.window {
+		t.recreations = t.recreations[1:]
+	}
+	return len(t.recreations)
+}
+
+// applyError wraps errors returned by applyFunc so they can be told apart
+// from errors encountered while evaluating whether an apply is needed.
+type applyError struct {
+	err error
+}
+
+func (e *applyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *applyError) Unwrap() error {
+	return e.err
+}
+
+// degradedReason classifies a sync error into a condition reason.
+// A NotFound returned by the apply itself, or a missing REST mapping, means
+// the CRD for the managed resource is not installed yet.
+func degradedReason(err error) string {
+	var ae *applyError
+	switch {
+	case meta.IsNoMatchError(err):
+		return reasonClusterCatalogCRDNotReady
+	case errors.As(err, &ae) && apierrors.IsNotFound(err):
+		return reasonClusterCatalogCRDNotReady
+	case errors.As(err, &ae):
+		return reasonClusterCatalogApplyFailed
+	default:
+		return reasonSyncError
+	}
 }
 
-func (c *dynamicRequiredManifestController) sync(ctx context.Context, _ factory.SyncContext) error {
+func (c *dynamicRequiredManifestController) syncAndReportDegraded(ctx context.Context, syncCtx factory.SyncContext) error {
+	err := c.sync(ctx, syncCtx)
+
+	condition := operatorv1apply.OperatorCondition().
+		WithType(c.name + "Degraded").
+		WithStatus(operatorv1.ConditionFalse).
+		WithReason(reasonAsExpected)
+	if err != nil {
+		condition = condition.
+			WithStatus(operatorv1.ConditionTrue).
+			WithReason(degradedReason(err)).
+			WithMessage(err.Error())
+	}
+
+	conditions := []*operatorv1apply.OperatorConditionApplyConfiguration{condition}
+	if c.recreations != nil {
+		deletion := operatorv1apply.OperatorCondition().
+			WithType(c.name + "ExternallyDeleted").
+			WithStatus(operatorv1.ConditionFalse).
+			WithReason(reasonAsExpected)
+		if recreations := c.recreations.count(); recreations >= c.recreations.threshold {
+			deletion = deletion.
+				WithStatus(operatorv1.ConditionTrue).
+				WithReason(reasonRepeatedExternalDeletion).
+				WithMessage(fmt.Sprintf("%s %q was deleted and re-created %d times within %s; another client may be deleting it", c.gvr, c.key, recreations, c.recreations.window))
+		}
+		conditions = append(conditions, deletion)
+	}
+
+	if updateErr := c.operatorClient.ApplyOperatorStatus(ctx, factory.ControllerFieldManager(c.name, "reportDegraded"), operatorv1apply.OperatorStatus().WithConditions(conditions...)); updateErr != nil {
+		if err != nil {
+			return errors.Join(err, updateErr)
+		}
+		return updateErr
+	}
+	return err
+}
+
+func (c *dynamicRequiredManifestController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	logger := klog.FromContext(ctx).WithName(c.name)
 	logger.V(2).Info("sync started")
 	defer logger.V(2).Info("sync finished")
@@ -149,14 +295,25 @@ func (c *dynamicRequiredManifestController) sync(ctx context.Context, _ factory.
 	}
 	if !managed {
 		logger.V(2).Info("not managed, skipping sync")
+		if c.recreations != nil {
+			// the resource may be removed along with the operands, which is no
+			// external deletion
+			c.recreations.forget()
+		}
 		return nil
 	}
 
 	obj, err := c.objectGetFunc(c.key)
-	if err != nil && !errors.IsNotFound(err) {
+	if err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("fetching %s %q: %w", c.gvr, c.key, err)
 	}
 
+	if c.recreations != nil {
+		if recreations := c.recreations.observe(obj != nil); obj == nil && recreations >= c.recreations.threshold && syncCtx != nil {
+			syncCtx.Recorder().Warningf(reasonRepeatedExternalDeletion, "%s %q was deleted and re-created %d times within %s; another client may be deleting it", c.gvr, c.key, recreations, c.recreations.window)
+		}
+	}
+
 	// in the event the catalog was not found, the supplied for the existing is nil and
 	// shouldUpdateFunc is expected to return true.
 	shouldUpdate, err := c.shouldUpdateFunc(c.manifest, obj)
@@ -165,17 +322,32 @@ func (c *dynamicRequiredManifestController) sync(ctx context.Context, _ factory.
 	}
 
 	if !shouldUpdate {
+		c.drifted = false
 		logger.V(4).Info("no updates needed")
 		return nil
 	}
 
+	// a missing resource is still created, only changes to an existing one are left in place
+	if c.reportOnly && obj != nil {
+		logger.V(2).Info(fmt.Sprintf("%s %q does not meet requirements, reporting only", c.gvr, c.key))
+		if !c.drifted && syncCtx != nil {
+			syncCtx.Recorder().Warningf("DriftDetected", "%s %q differs from the required manifest; not reverting because the controller is in report-only mode", c.gvr, c.key)
+		}
+		c.drifted = true
+		return nil
+	}
+	c.drifted = false
+
 	logger.V(2).Info(fmt.Sprintf("%s %q does not meet requirements, applying ...", c.gvr, c.key))
-	return c.applyFunc(
+	if err := c.applyFunc(
 		ctx,
 		c.key,
 		c.name,
 		true,
 		c.gvr,
 		c.manifest,
-	)
+	); err != nil {
+		return &applyError{err: fmt.Errorf("applying %s %q: %w", c.gvr, c.key, err)}
+	}
+	return nil
 }
@@ -5,13 +5,20 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	catalogdv1 "github.com/operator-framework/catalogd/api/v1"
 )
@@ -163,6 +170,218 @@ func TestDynamicRequiredManifestControllerSync(t *testing.T) {
 	}
 }
 
+func TestDynamicRequiredManifestControllerReportOnly(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		existing      runtime.Object
+		expectApplied bool
+		expectEvent   bool
+	}{
+		{
+			name:        "existing resource drifted, drift reported, not applied",
+			existing:    &unstructured.Unstructured{},
+			expectEvent: true,
+		},
+		{
+			name:          "resource missing, applied",
+			expectApplied: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			applied := false
+			c := &dynamicRequiredManifestController{
+				name:        "foo",
+				key:         types.NamespacedName{Name: "foo"},
+				reportOnly:  true,
+				managedFunc: func() (bool, error) { return true, nil },
+				objectGetFunc: func(key types.NamespacedName) (runtime.Object, error) {
+					if tc.existing == nil {
+						return nil, apierrors.NewNotFound(catalogdv1.GroupVersion.WithResource("clustercatalogs").GroupResource(), key.Name)
+					}
+					return tc.existing, nil
+				},
+				shouldUpdateFunc: func(_ []byte, _ runtime.Object) (bool, error) {
+					return true, nil
+				},
+				applyFunc: func(_ context.Context, _ types.NamespacedName, _ string, _ bool, _ schema.GroupVersionResource, _ []byte) error {
+					applied = true
+					return nil
+				},
+			}
+
+			recorder := events.NewInMemoryRecorder("test")
+			if err := c.sync(context.TODO(), factory.NewSyncContext("test", recorder)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if applied != tc.expectApplied {
+				t.Errorf("expected applied: %v, got: %v", tc.expectApplied, applied)
+			}
+			var driftEvents int
+			for _, e := range recorder.Events() {
+				if e.Reason == "DriftDetected" {
+					driftEvents++
+				}
+			}
+			if (driftEvents > 0) != tc.expectEvent {
+				t.Errorf("expected drift event: %v, got events: %v", tc.expectEvent, recorder.Events())
+			}
+		})
+	}
+}
+
+func TestDynamicRequiredManifestControllerReportOnlyDriftTransitions(t *testing.T) {
+	drifted := true
+	c := &dynamicRequiredManifestController{
+		name:        "foo",
+		key:         types.NamespacedName{Name: "foo"},
+		reportOnly:  true,
+		managedFunc: func() (bool, error) { return true, nil },
+		objectGetFunc: func(_ types.NamespacedName) (runtime.Object, error) {
+			return &unstructured.Unstructured{}, nil
+		},
+		shouldUpdateFunc: func(_ []byte, _ runtime.Object) (bool, error) {
+			return drifted, nil
+		},
+		applyFunc: func(_ context.Context, _ types.NamespacedName, _ string, _ bool, _ schema.GroupVersionResource, _ []byte) error {
+			t.Fatal("unexpected apply in report-only mode")
+			return nil
+		},
+	}
+
+	// a sync without a sync context must not panic
+	if err := c.sync(context.TODO(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.drifted = false
+
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext("test", recorder)
+	driftEvents := func() int {
+		var n int
+		for _, e := range recorder.Events() {
+			if e.Reason == "DriftDetected" {
+				n++
+			}
+		}
+		return n
+	}
+	for _, step := range []struct {
+		drifted        bool
+		expectedEvents int
+	}{
+		{drifted: true, expectedEvents: 1},
+		{drifted: true, expectedEvents: 1},
+		{drifted: false, expectedEvents: 1},
+		{drifted: true, expectedEvents: 2},
+	} {
+		drifted = step.drifted
+		if err := c.sync(context.TODO(), syncCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if actual := driftEvents(); actual != step.expectedEvents {
+			t.Fatalf("expected %d drift events after a sync with drifted=%v, got %d: %v", step.expectedEvents, step.drifted, actual, recorder.Events())
+		}
+	}
+}
+
+func TestDynamicRequiredManifestControllerDegradedReason(t *testing.T) {
+	clusterCatalogsGR := catalogdv1.GroupVersion.WithResource("clustercatalogs").GroupResource()
+	applyReturning := func(err error) applyFunc {
+		return func(_ context.Context, _ types.NamespacedName, _ string, _ bool, _ schema.GroupVersionResource, _ []byte) error {
+			return err
+		}
+	}
+	getReturning := func(err error) getObjectFunc {
+		return func(_ types.NamespacedName) (runtime.Object, error) {
+			return nil, err
+		}
+	}
+
+	for _, tc := range []struct {
+		name            string
+		applyFunc       applyFunc
+		objectGetFunc   getObjectFunc
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectSyncError bool
+	}{
+		{
+			name:           "apply succeeds, not degraded",
+			applyFunc:      applyReturning(nil),
+			objectGetFunc:  getReturning(apierrors.NewNotFound(clusterCatalogsGR, "foo")),
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name:            "apply returns not found, CRD not ready",
+			applyFunc:       applyReturning(apierrors.NewNotFound(clusterCatalogsGR, "foo")),
+			objectGetFunc:   getReturning(apierrors.NewNotFound(clusterCatalogsGR, "foo")),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonClusterCatalogCRDNotReady,
+			expectSyncError: true,
+		},
+		{
+			name:            "no REST mapping for the resource, CRD not ready",
+			applyFunc:       applyReturning(nil),
+			objectGetFunc:   getReturning(&meta.NoKindMatchError{GroupKind: catalogdv1.GroupVersion.WithKind("ClusterCatalog").GroupKind()}),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonClusterCatalogCRDNotReady,
+			expectSyncError: true,
+		},
+		{
+			name:            "apply returns conflict, apply failed",
+			applyFunc:       applyReturning(apierrors.NewConflict(clusterCatalogsGR, "foo", errors.New("boom"))),
+			objectGetFunc:   getReturning(apierrors.NewNotFound(clusterCatalogsGR, "foo")),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonClusterCatalogApplyFailed,
+			expectSyncError: true,
+		},
+		{
+			name:            "fetch fails, generic sync error",
+			applyFunc:       applyReturning(nil),
+			objectGetFunc:   getReturning(errors.New("boom")),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonSyncError,
+			expectSyncError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			ctrl := &dynamicRequiredManifestController{
+				name:        "Foo",
+				key:         types.NamespacedName{Name: "foo"},
+				managedFunc: func() (bool, error) { return true, nil },
+				shouldUpdateFunc: func(_ []byte, _ runtime.Object) (bool, error) {
+					return true, nil
+				},
+				applyFunc:      tc.applyFunc,
+				objectGetFunc:  tc.objectGetFunc,
+				operatorClient: operatorClient,
+			}
+
+			err := ctrl.syncAndReportDegraded(context.TODO(), nil)
+			if tc.expectSyncError != (err != nil) {
+				t.Fatalf("expected sync error: %v, got: %v", tc.expectSyncError, err)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatalf("unexpected error getting operator state: %v", err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, "FooDegraded")
+			if cond == nil {
+				t.Fatal("expected FooDegraded condition to be set")
+			}
+			if cond.Status != tc.expectedStatus {
+				t.Errorf("expected status %q, got %q", tc.expectedStatus, cond.Status)
+			}
+			if cond.Reason != tc.expectedReason {
+				t.Errorf("expected reason %q, got %q", tc.expectedReason, cond.Reason)
+			}
+		})
+	}
+}
+
 func TestUnstructuredShouldUpdateFunc(t *testing.T) {
 	for _, tc := range []struct {
 		name         string
@@ -259,3 +478,148 @@ spec:
       pollInterval: 10m0s
       ref: registry.redhat.io/redhat/certified-operator-index:v4.18
 `
+
+func TestDynamicRequiredManifestControllerRepeatedDeletion(t *testing.T) {
+	clusterCatalogsGR := catalogdv1.GroupVersion.WithResource("clustercatalogs").GroupResource()
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	exists := true
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	ctrl := &dynamicRequiredManifestController{
+		name:        "Foo",
+		key:         types.NamespacedName{Name: "foo"},
+		gvr:         catalogdv1.GroupVersion.WithResource("clustercatalogs"),
+		managedFunc: func() (bool, error) { return true, nil },
+		objectGetFunc: func(key types.NamespacedName) (runtime.Object, error) {
+			if !exists {
+				return nil, apierrors.NewNotFound(clusterCatalogsGR, key.Name)
+			}
+			return &unstructured.Unstructured{}, nil
+		},
+		shouldUpdateFunc: func(_ []byte, existing runtime.Object) (bool, error) {
+			return existing == nil, nil
+		},
+		applyFunc: func(_ context.Context, _ types.NamespacedName, _ string, _ bool, _ schema.GroupVersionResource, _ []byte) error {
+			exists = true
+			return nil
+		},
+		operatorClient: operatorClient,
+		recreations:    newRecreationTracker(3, 10*time.Minute, fakeClock),
+	}
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext("test", recorder)
+
+	deletionCondition := func() *operatorv1.OperatorCondition {
+		t.Helper()
+		_, status, _, err := operatorClient.GetOperatorState()
+		if err != nil {
+			t.Fatalf("unexpected error getting operator state: %v", err)
+		}
+		cond := v1helpers.FindOperatorCondition(status.Conditions, "FooExternallyDeleted")
+		if cond == nil {
+			t.Fatal("expected FooExternallyDeleted condition to be set")
+		}
+		return cond
+	}
+	warnings := func() int {
+		var count int
+		for _, e := range recorder.Events() {
+			if e.Reason == reasonRepeatedExternalDeletion {
+				count++
+			}
+		}
+		return count
+	}
+	deleteAndSync := func() {
+		t.Helper()
+		exists = false
+		fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+		if err := ctrl.syncAndReportDegraded(context.TODO(), syncCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// the re-created resource is observed on the next sync
+		if err := ctrl.syncAndReportDegraded(context.TODO(), syncCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := ctrl.syncAndReportDegraded(context.TODO(), syncCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		deleteAndSync()
+	}
+	if cond := deletionCondition(); cond.Status != operatorv1.ConditionFalse || warnings() != 0 {
+		t.Fatalf("expected no deletion warning below the threshold, got condition %+v and events %v", cond, recorder.Events())
+	}
+
+	deleteAndSync()
+	if cond := deletionCondition(); cond.Status != operatorv1.ConditionTrue || cond.Reason != reasonRepeatedExternalDeletion {
+		t.Errorf("expected the deletion condition to be True with reason %q, got %+v", reasonRepeatedExternalDeletion, cond)
+	}
+	if warnings() != 1 {
+		t.Errorf("expected a single deletion warning event, got %v", recorder.Events())
+	}
+
+	// the deletions age out of the window
+	fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+	if err := ctrl.syncAndReportDegraded(context.TODO(), syncCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cond := deletionCondition(); cond.Status != operatorv1.ConditionFalse {
+		t.Errorf("expected the deletion condition to be False once the deletions aged out, got %+v", cond)
+	}
+}
+
+func TestDynamicRequiredManifestControllerRemovalIsNotExternalDeletion(t *testing.T) {
+	clusterCatalogsGR := catalogdv1.GroupVersion.WithResource("clustercatalogs").GroupResource()
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	exists, managed := true, true
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	ctrl := &dynamicRequiredManifestController{
+		name:        "Foo",
+		key:         types.NamespacedName{Name: "foo"},
+		gvr:         catalogdv1.GroupVersion.WithResource("clustercatalogs"),
+		managedFunc: func() (bool, error) { return managed, nil },
+		objectGetFunc: func(key types.NamespacedName) (runtime.Object, error) {
+			if !exists {
+				return nil, apierrors.NewNotFound(clusterCatalogsGR, key.Name)
+			}
+			return &unstructured.Unstructured{}, nil
+		},
+		shouldUpdateFunc: func(_ []byte, existing runtime.Object) (bool, error) {
+			return existing == nil, nil
+		},
+		applyFunc: func(_ context.Context, _ types.NamespacedName, _ string, _ bool, _ schema.GroupVersionResource, _ []byte) error {
+			exists = true
+			return nil
+		},
+		operatorClient: operatorClient,
+		recreations:    newRecreationTracker(3, 10*time.Minute, fakeClock),
+	}
+	recorder := events.NewInMemoryRecorder("test")
+	syncCtx := factory.NewSyncContext("test", recorder)
+	sync := func() {
+		t.Helper()
+		fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+		if err := ctrl.syncAndReportDegraded(context.TODO(), syncCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	sync()
+	for i := 0; i < 3; i++ {
+		// the operands are removed, then managed again
+		managed, exists = false, false
+		sync()
+		managed = true
+		sync()
+		sync()
+	}
+
+	assertOperatorCondition(t, operatorClient, "FooExternallyDeleted", operatorv1.ConditionFalse, reasonAsExpected, "")
+	for _, e := range recorder.Events() {
+		if e.Reason == reasonRepeatedExternalDeletion {
+			t.Errorf("expected no deletion warning, got %v", e)
+		}
+	}
+}
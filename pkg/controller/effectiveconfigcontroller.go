@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	// EffectiveConfigConfigMap holds, in the operator namespace, the effective runtime
+	// configuration of the operator.
+	EffectiveConfigConfigMap = "cluster-olm-operator-effective-config"
+	effectiveConfigKey       = "config.json"
+
+	effectiveConfigResyncInterval = 10 * time.Minute
+)
+
+// EffectiveConfig is the runtime configuration of the operator, as computed at startup
+// from its flags, environment and operand assets.
+type EffectiveConfig struct {
+	OperatorVersion      string            `json:"operatorVersion"`
+	OCPVersion           string            `json:"ocpVersion"`
+	NextOCPMinorVersion  string            `json:"nextOCPMinorVersion"`
+	OperandImages        map[string]string `json:"operandImages"`
+	ClusterCatalogImages map[string]string `json:"clusterCatalogImages,omitempty"`
+	ResyncPeriod         string            `json:"resyncPeriod"`
+	ObserveOnly          bool              `json:"observeOnly"`
+	OperandConfigPath    string            `json:"operandConfigPath,omitempty"`
+	ExcludedManifests    []string          `json:"excludedManifests,omitempty"`
+	RenderWarnings       []string          `json:"renderWarnings,omitempty"`
+}
+
+// NewEffectiveConfigController returns a controller maintaining the effective config
+// ConfigMap in the given namespace, reverting changes made to it on resync.
+func NewEffectiveConfigController(name, namespace string, config EffectiveConfig, kubeClient corev1client.ConfigMapsGetter, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &effectiveConfigController{
+		name:       name,
+		namespace:  namespace,
+		config:     config,
+		kubeClient: kubeClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer()).ResyncEvery(effectiveConfigResyncInterval).ToController(name, eventRecorder)
+}
+
+type effectiveConfigController struct {
+	name       string
+	namespace  string
+	config     EffectiveConfig
+	kubeClient corev1client.ConfigMapsGetter
+}
+
+func (c *effectiveConfigController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	required, err := effectiveConfigMap(c.namespace, c.config)
+	if err != nil {
+		return err
+	}
+	if _, _, err := resourceapply.ApplyConfigMap(ctx, c.kubeClient, syncCtx.Recorder(), required); err != nil {
+		return fmt.Errorf("applying ConfigMap %s/%s: %w", c.namespace, EffectiveConfigConfigMap, err)
+	}
+	return nil
+}
+
+// effectiveConfigMap returns the ConfigMap publishing the configuration.
+func effectiveConfigMap(namespace string, config EffectiveConfig) (*corev1.ConfigMap, error) {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the effective config: %w", err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      EffectiveConfigConfigMap,
+		},
+		Data: map[string]string{effectiveConfigKey: string(data)},
+	}, nil
+}
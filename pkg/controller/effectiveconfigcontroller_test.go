@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEffectiveConfigControllerSync(t *testing.T) {
+	ctx := context.Background()
+	config := EffectiveConfig{
+		OperatorVersion:      "4.18.0",
+		OCPVersion:           "4.18.0",
+		NextOCPMinorVersion:  "4.19.0",
+		OperandImages:        map[string]string{"CATALOGD_IMAGE": "quay.io/openshift/catalogd:latest"},
+		ClusterCatalogImages: map[string]string{"openshift-redhat-operators": "registry.redhat.io/redhat/redhat-operator-index:v4.18"},
+		ResyncPeriod:         "10m0s",
+		ExcludedManifests:    []string{"catalogd/*-servicemonitor.yaml"},
+	}
+	// changes made to the ConfigMap are reverted
+	edited := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-cluster-olm-operator", Name: EffectiveConfigConfigMap},
+		Data:       map[string]string{effectiveConfigKey: `{"observeOnly":true}`},
+	}
+	kubeClient := kubefake.NewSimpleClientset(edited)
+	c := &effectiveConfigController{
+		name:       "test",
+		namespace:  "openshift-cluster-olm-operator",
+		config:     config,
+		kubeClient: kubeClient.CoreV1(),
+	}
+
+	if err := c.sync(ctx, factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm, err := kubeClient.CoreV1().ConfigMaps("openshift-cluster-olm-operator").Get(ctx, EffectiveConfigConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var published EffectiveConfig
+	if err := json.Unmarshal([]byte(cm.Data[effectiveConfigKey]), &published); err != nil {
+		t.Fatalf("unexpected error decoding %q: %v", cm.Data[effectiveConfigKey], err)
+	}
+	if !reflect.DeepEqual(config, published) {
+		t.Errorf("expected the published config %+v, got %+v", config, published)
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// finalizerRemover reads the live metadata of the OLM resource and removes its
+// finalizers.
+type finalizerRemover interface {
+	GetObjectMetaWithContext(ctx context.Context) (*metav1.ObjectMeta, error)
+	RemoveFinalizer(ctx context.Context, finalizer string) error
+}
+
+// RemoveRetiredFinalizers removes from the OLM resource the finalizers that previous
+// versions of the operator used to set and that would otherwise block its deletion.
+// Finalizers not in retired are left in place.
+func RemoveRetiredFinalizers(ctx context.Context, operatorClient finalizerRemover, retired []string) error {
+	if len(retired) == 0 {
+		return nil
+	}
+	logger := klog.FromContext(ctx).WithName("finalizers")
+
+	meta, err := operatorClient.GetObjectMetaWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting the OLM resource: %w", err)
+	}
+
+	retiredSet := sets.New(retired...)
+	var errs []error
+	for _, finalizer := range meta.Finalizers {
+		if !retiredSet.Has(finalizer) {
+			continue
+		}
+		if err := operatorClient.RemoveFinalizer(ctx, finalizer); err != nil {
+			errs = append(errs, fmt.Errorf("error removing retired finalizer %q: %w", finalizer, err))
+			continue
+		}
+		logger.Info("removed retired finalizer from the OLM resource", "finalizer", finalizer)
+	}
+	return errors.Join(errs...)
+}
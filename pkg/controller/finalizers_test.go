@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeFinalizerRemover adds the context-aware metadata read of the operator client to
+// the fake one.
+type fakeFinalizerRemover struct {
+	v1helpers.OperatorClientWithFinalizers
+}
+
+func (f fakeFinalizerRemover) GetObjectMetaWithContext(ctx context.Context) (*metav1.ObjectMeta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.GetObjectMeta()
+}
+
+func TestRemoveRetiredFinalizers(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		finalizers []string
+		retired    []string
+		expected   []string
+	}{
+		{
+			name:       "retired finalizer removed, current one preserved",
+			finalizers: []string{"olm.openshift.io/cleanup", "operator.openshift.io/old-cleanup"},
+			retired:    []string{"operator.openshift.io/old-cleanup"},
+			expected:   []string{"olm.openshift.io/cleanup"},
+		},
+		{
+			name:       "no retired finalizers present",
+			finalizers: []string{"olm.openshift.io/cleanup"},
+			retired:    []string{"operator.openshift.io/old-cleanup"},
+			expected:   []string{"olm.openshift.io/cleanup"},
+		},
+		{
+			name:       "no retired finalizers configured",
+			finalizers: []string{"olm.openshift.io/cleanup", "operator.openshift.io/old-cleanup"},
+			expected:   []string{"olm.openshift.io/cleanup", "operator.openshift.io/old-cleanup"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(
+				&metav1.ObjectMeta{Name: "cluster", Finalizers: tc.finalizers},
+				&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed},
+				&operatorv1.OperatorStatus{},
+				nil,
+			)
+
+			if err := RemoveRetiredFinalizers(context.Background(), fakeFinalizerRemover{operatorClient}, tc.retired); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			meta, err := operatorClient.GetObjectMeta()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.expected, meta.Finalizers) {
+				t.Errorf("expected finalizers %v, got %v", tc.expected, meta.Finalizers)
+			}
+		})
+	}
+}
+
+func TestRemoveRetiredFinalizersCancelledContext(t *testing.T) {
+	operatorClient := v1helpers.NewFakeOperatorClientWithObjectMeta(
+		&metav1.ObjectMeta{Name: "cluster", Finalizers: []string{"operator.openshift.io/old-cleanup"}},
+		&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed},
+		&operatorv1.OperatorStatus{},
+		nil,
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := RemoveRetiredFinalizers(ctx, fakeFinalizerRemover{operatorClient}, []string{"operator.openshift.io/old-cleanup"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation error, got %v", err)
+	}
+}
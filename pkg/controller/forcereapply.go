@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/klog/v2"
+)
+
+// forceReapplyAnnotation, set on the OLM resource, has the operand Deployments
+// re-applied once for every new value, e.g. forced by a support engineer with
+// oc annotate olm cluster olm.openshift.io/force-rerender="$(date +%s)" --overwrite.
+// Static resources are already re-applied on every sync.
+const forceReapplyAnnotation = "olm.openshift.io/force-rerender"
+
+// forceReapplyHook stamps the value of the force re-apply annotation of the OLM resource
+// on the Deployment. A new value changes the rendered metadata, which has the deployment
+// controller write the whole Deployment; an unchanged or removed value has no effect.
+func forceReapplyHook(olmAnnotationFunc func(key string) (string, error), deploymentLister appsv1listers.DeploymentLister) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		value, err := olmAnnotationFunc(forceReapplyAnnotation)
+		if err != nil {
+			return fmt.Errorf("error reading the %s annotation of the OLM resource: %w", forceReapplyAnnotation, err)
+		}
+		if value == "" {
+			return nil
+		}
+		existing, err := deploymentLister.Deployments(deployment.Namespace).Get(deployment.Name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("fetching Deployment %s/%s: %w", deployment.Namespace, deployment.Name, err)
+		}
+		if existing != nil && existing.Annotations[forceReapplyAnnotation] != value {
+			klog.NewKlogr().WithName("builder").Info("forced re-apply requested through the OLM resource", "deployment", deployment.Namespace+"/"+deployment.Name, "value", value)
+		}
+		if deployment.Annotations == nil {
+			deployment.Annotations = map[string]string{}
+		}
+		deployment.Annotations[forceReapplyAnnotation] = value
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestForceReapplyHook(t *testing.T) {
+	ctx := context.Background()
+	rendered := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "manager", Image: "catalogd:latest"}}},
+				},
+			},
+		}
+	}
+	kubeClient := kubefake.NewSimpleClientset()
+	recorder := events.NewInMemoryRecorder("test")
+
+	annotation := ""
+	apply := func(t *testing.T) bool {
+		t.Helper()
+		var existing []*appsv1.Deployment
+		if live, err := kubeClient.AppsV1().Deployments("openshift-catalogd").Get(ctx, "catalogd-controller-manager", metav1.GetOptions{}); err == nil {
+			existing = append(existing, live)
+		}
+		hook := forceReapplyHook(func(key string) (string, error) {
+			if key != forceReapplyAnnotation {
+				t.Fatalf("unexpected annotation %q requested", key)
+			}
+			return annotation, nil
+		}, deploymentLister(t, existing...))
+
+		required := rendered()
+		if err := hook(nil, required); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectedGeneration := int64(-1)
+		if len(existing) > 0 {
+			expectedGeneration = existing[0].Generation
+		}
+		_, modified, err := resourceapply.ApplyDeployment(ctx, kubeClient.AppsV1(), recorder, required, expectedGeneration)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return modified
+	}
+
+	if !apply(t) {
+		t.Fatal("expected the Deployment to be created")
+	}
+	if apply(t) {
+		t.Fatal("expected no write without the annotation")
+	}
+
+	annotation = "1"
+	if !apply(t) {
+		t.Fatal("expected a new annotation value to re-apply the Deployment")
+	}
+	if apply(t) {
+		t.Fatal("expected the same annotation value to re-apply the Deployment only once")
+	}
+
+	annotation = "2"
+	if !apply(t) {
+		t.Fatal("expected another annotation value to re-apply the Deployment")
+	}
+	live, err := kubeClient.AppsV1().Deployments("openshift-catalogd").Get(ctx, "catalogd-controller-manager", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := live.Annotations[forceReapplyAnnotation]; actual != "2" {
+		t.Errorf("expected annotation value %q, got %q", "2", actual)
+	}
+}
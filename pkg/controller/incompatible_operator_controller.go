@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	semver "github.com/blang/semver/v4"
 	"github.com/go-logr/logr"
 	operatorv1 "github.com/openshift/api/operator/v1"
-	"github.com/openshift/cluster-olm-operator/internal/utils"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 	"github.com/openshift/cluster-olm-operator/pkg/clients"
+	"github.com/openshift/cluster-olm-operator/pkg/compatibility"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -20,55 +23,174 @@ import (
 	"github.com/operator-framework/operator-registry/alpha/property"
 	helm "helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 )
 
 const (
 	reasonIncompatibleOperatorsInstalled = "IncompatibleOperatorsInstalled"
 	typeIncompatibelOperatorsUpgradeable = "InstalledOLMOperatorsUpgradeable"
 	reasonFailureGettingExtension        = "FailureGettingExtensionMetadata"
-	maxOpenShiftVersionProperty          = "olm.maxOpenShiftVersion"
 	ownerKindKey                         = "olm.operatorframework.io/owner-kind"
 	ownerNameKey                         = "olm.operatorframework.io/owner-name"
 	packageNameKey                       = "olm.operatorframework.io/package-name"
 	bundleNameKey                        = "olm.operatorframework.io/bundle-name"
 	bundleVersionKey                     = "olm.operatorframework.io/bundle-version"
+
+	// DefaultMaxListedIncompatibleOperators is the default number of incompatible
+	// operators named in the condition message before the rest are summarized.
+	DefaultMaxListedIncompatibleOperators = 10
+
+	// helmStorageOwner is the owner operator-controller records on its Helm release secrets.
+	helmStorageOwner = "operator-controller"
+
+	// incompatibleOperatorsAnnotation holds, on the OLM resource, a JSON array of the
+	// incompatible operators reported by the upgradeable condition, for upgrade tooling.
+	incompatibleOperatorsAnnotation = "olm.openshift.io/incompatible-operators"
+)
+
+// incompatibleOperator is an installed bundle preventing the cluster upgrade.
+type incompatibleOperator struct {
+	// Name is the name of the ClusterExtension.
+	Name       string `json:"name"`
+	Bundle     string `json:"bundle"`
+	Version    string `json:"version,omitempty"`
+	Package    string `json:"package,omitempty"`
+	MaxVersion string `json:"maxVersion,omitempty"`
+
+	reasons []string
+}
+
+func (o incompatibleOperator) String() string {
+	return fmt.Sprintf("bundle %q for ClusterExtension %q (%s)", o.Bundle, o.Name, strings.Join(o.reasons, "; "))
+}
+
+// The decisions recorded in a scanResult.
+const (
+	decisionCompatible   = "Compatible"
+	decisionIncompatible = "Incompatible"
+	decisionNotDeployed  = "NotDeployed"
+	// decisionUnknown is recorded when the extension could not be evaluated.
+	decisionUnknown = "Unknown"
 )
 
+// scanResult is the outcome of evaluating an installed ClusterExtension.
+type scanResult struct {
+	Name       string   `json:"name"`
+	Bundle     string   `json:"bundle,omitempty"`
+	Version    string   `json:"version,omitempty"`
+	Package    string   `json:"package,omitempty"`
+	MaxVersion string   `json:"maxVersion,omitempty"`
+	Decision   string   `json:"decision"`
+	Reasons    []string `json:"reasons,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// annotationSetter sets annotations on the OLM resource.
+type annotationSetter interface {
+	SetAnnotation(ctx context.Context, key, value string) error
+}
+
 type incompatibleOperatorController struct {
 	name                   string
 	nextOCPMinorVersion    *semver.Version
+	clusterVersionLister   configv1listers.ClusterVersionLister
 	kubeclient             kubernetes.Interface
 	clusterExtensionClient *clients.ClusterExtensionClient
-	operatorClient         *clients.OperatorClient
+	operatorClient         v1helpers.OperatorClient
+	checks                 []compatibility.Check
+	maxListedOperators     int
 	logger                 logr.Logger
+	olmAnnotations         annotationSetter
+	metrics                *incompatibleOperatorMetrics
+
+	// helmReleaseNamespace is the namespace operator-controller stores its Helm release secrets in.
+	helmReleaseNamespace string
+	helmStorageOwner     string
+
+	// listClusterExtensions, when set, replaces the informer as the source of ClusterExtensions.
+	listClusterExtensions func(ctx context.Context) ([]runtime.Object, error)
+
+	// incompatibleOperatorsFunc returns the incompatible operators for the target version.
+	incompatibleOperatorsFunc func(ctx context.Context, targetVersion semver.Version) ([]incompatibleOperator, error)
+	// gracePeriod is how long incompatible operators must persist before the
+	// upgradeable condition is set to False.
+	gracePeriod       time.Duration
+	incompatibleSince time.Time
+	clock             clock.PassiveClock
 }
 
-func NewIncompatibleOperatorController(name string, nextOCPMinorVersion *semver.Version, kubeclient kubernetes.Interface, clusterExtensionClient *clients.ClusterExtensionClient, operatorClient *clients.OperatorClient, eventRecorder events.Recorder) factory.Controller {
+// NewIncompatibleOperatorController returns a controller reporting whether the installed
+// ClusterExtensions allow a cluster upgrade. At most maxListedOperators incompatible
+// operators are named in the condition message; a non-positive value names all of them.
+// The condition only becomes False once incompatible operators were found for gracePeriod.
+// Helm release secrets are looked up in helmReleaseNamespace, the namespace operator-controller
+// is deployed to.
+func NewIncompatibleOperatorController(name string, nextOCPMinorVersion *semver.Version, clusterVersionInformer configv1informers.ClusterVersionInformer, kubeclient kubernetes.Interface, clusterExtensionClient *clients.ClusterExtensionClient, operatorClient *clients.OperatorClient, helmReleaseNamespace string, maxListedOperators int, gracePeriod time.Duration, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
 	c := &incompatibleOperatorController{
 		name:                   name,
 		nextOCPMinorVersion:    nextOCPMinorVersion,
+		clusterVersionLister:   clusterVersionInformer.Lister(),
 		kubeclient:             kubeclient,
 		clusterExtensionClient: clusterExtensionClient,
 		operatorClient:         operatorClient,
+		olmAnnotations:         operatorClient,
+		metrics:                newIncompatibleOperatorMetrics(incompatibleOperatorGauge, maxIncompatibleOperatorSeries),
+		checks:                 []compatibility.Check{compatibility.MaxOpenShiftVersionCheck},
+		maxListedOperators:     maxListedOperators,
 		logger:                 klog.NewKlogr().WithName(name),
+		gracePeriod:            gracePeriod,
+		helmReleaseNamespace:   helmReleaseNamespace,
+		helmStorageOwner:       helmStorageOwner,
+		clock:                  clock.RealClock{},
 	}
+	c.incompatibleOperatorsFunc = c.getIncompatibleOperators
 
-	return factory.New().WithSync(c.sync).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), clusterExtensionClient.Informer().Informer()).ToController(name, eventRecorder)
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), clusterExtensionClient.Informer().Informer(), clusterVersionInformer.Informer()).ToController(name, eventRecorder)
 }
 
-func (c *incompatibleOperatorController) sync(ctx context.Context, _ factory.SyncContext) error {
+func (c *incompatibleOperatorController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	c.logger.Info("sync started")
 	defer c.logger.Info("sync finished")
 
+	clusterVersion, err := c.clusterVersionLister.Get("version")
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error getting clusterversions.config.openshift.io/version: %w", err)
+	}
+	var desiredUpdateVersion string
+	if clusterVersion != nil && clusterVersion.Spec.DesiredUpdate != nil {
+		desiredUpdateVersion = clusterVersion.Spec.DesiredUpdate.Version
+	}
+	targetVersion := compatibility.UpgradeTargetMinorVersion(*c.nextOCPMinorVersion, desiredUpdateVersion)
+
 	var updateStatusFn v1helpers.UpdateStatusFunc
-	incompatibleOperators, err := c.getIncompatibleOperators()
+	incompatibleOperators, err := c.incompatibleOperatorsFunc(ctx, targetVersion)
+	// keep the published series when the ClusterExtensions could not be evaluated,
+	// rather than deleting them on a transient failure
+	if c.metrics != nil && (err == nil || incompatibleOperators != nil) {
+		c.metrics.set(c.logger, incompatibleOperators)
+	}
+	if len(incompatibleOperators) == 0 {
+		c.incompatibleSince = time.Time{}
+	} else if c.incompatibleSince.IsZero() {
+		c.incompatibleSince = c.clock.Now()
+	}
 	if len(incompatibleOperators) > 0 {
-		message := fmt.Sprintf("Found ClusterExtensions that require upgrades prior to upgrading cluster to version %d.%d: %s.", c.nextOCPMinorVersion.Major, c.nextOCPMinorVersion.Minor, strings.Join(incompatibleOperators, ","))
+		if remaining := c.gracePeriod - c.clock.Since(c.incompatibleSince); remaining > 0 {
+			c.logger.Info("Found incompatible ClusterExtensions, waiting for the grace period before reporting", "remaining", remaining)
+			if syncCtx != nil {
+				syncCtx.Queue().AddAfter(syncCtx.QueueKey(), remaining)
+			}
+			return err
+		}
+		message := fmt.Sprintf("Found ClusterExtensions that require upgrades prior to upgrading cluster to version %d.%d: %s.", targetVersion.Major, targetVersion.Minor, summarizeOperators(describeOperators(incompatibleOperators), c.maxListedOperators))
 		if err != nil {
 			message += fmt.Sprintf("\n Additionally the following errors were encountered while getting extension metadata: %s", err.Error())
 		}
@@ -98,20 +220,85 @@ func (c *incompatibleOperatorController) sync(ctx context.Context, _ factory.Syn
 		c.logger.Info(fmt.Sprintf("Error updating operator condition status: %v", updateErr))
 		return updateErr
 	}
+	if annotateErr := c.annotateIncompatibleOperators(ctx, incompatibleOperators); annotateErr != nil {
+		return errors.Join(err, annotateErr)
+	}
 	return err
 }
 
-func (c *incompatibleOperatorController) getIncompatibleOperators() ([]string, error) {
-	var incompatibleOperators []string
+// annotateIncompatibleOperators records the incompatible operators on the OLM resource,
+// clearing the annotation when there are none.
+func (c *incompatibleOperatorController) annotateIncompatibleOperators(ctx context.Context, operators []incompatibleOperator) error {
+	var value string
+	if len(operators) > 0 {
+		data, err := json.Marshal(operators)
+		if err != nil {
+			return fmt.Errorf("error encoding incompatible operators: %w", err)
+		}
+		value = string(data)
+	}
+	return c.olmAnnotations.SetAnnotation(ctx, incompatibleOperatorsAnnotation, value)
+}
+
+// summarizeOperators joins at most limit operators, summarizing the remainder
+// as "and N more" so the condition message stays bounded.
+func summarizeOperators(operators []string, limit int) string {
+	if limit <= 0 || len(operators) <= limit {
+		return strings.Join(operators, ",")
+	}
+	return fmt.Sprintf("%s, and %d more", strings.Join(operators[:limit], ","), len(operators)-limit)
+}
+
+func describeOperators(operators []incompatibleOperator) []string {
+	descriptions := make([]string, 0, len(operators))
+	for _, o := range operators {
+		descriptions = append(descriptions, o.String())
+	}
+	return descriptions
+}
+
+func (c *incompatibleOperatorController) getIncompatibleOperators(ctx context.Context, targetVersion semver.Version) ([]incompatibleOperator, error) {
+	results, err := c.scanClusterExtensions(ctx, targetVersion)
+	if results == nil {
+		return nil, err
+	}
+
+	var incompatibleOperators []incompatibleOperator
+	for _, result := range results {
+		if result.Decision == decisionIncompatible {
+			incompatibleOperators = append(incompatibleOperators, incompatibleOperator{
+				Name:       result.Name,
+				Bundle:     result.Bundle,
+				Version:    result.Version,
+				Package:    result.Package,
+				MaxVersion: result.MaxVersion,
+				reasons:    result.Reasons,
+			})
+		}
+	}
+
+	// deterministic ordering
+	sort.Slice(incompatibleOperators, func(i, j int) bool {
+		return incompatibleOperators[i].String() < incompatibleOperators[j].String()
+	})
+
+	return incompatibleOperators, err
+}
 
-	ceList, err := c.clusterExtensionClient.Informer().Lister().List(labels.NewSelector())
+// scanClusterExtensions evaluates every installed ClusterExtension against the target
+// version and returns the outcome for each, sorted by name. The errors of the
+// extensions that could not be evaluated are joined; a nil result means the
+// ClusterExtensions could not be listed.
+func (c *incompatibleOperatorController) scanClusterExtensions(ctx context.Context, targetVersion semver.Version) ([]scanResult, error) {
+	ceList, err := c.clusterExtensions(ctx)
 	if err != nil {
 		c.logger.Error(err, "Error listing cluster extensions")
 		return nil, err
 	}
 
-	store := c.buildHelmStore(c.kubeclient.CoreV1().Secrets("openshift-operator-controller"))
+	store := c.buildHelmStore(contextSecretClient{SecretInterface: c.kubeclient.CoreV1().Secrets(c.helmReleaseNamespace), ctx: ctx})
 
+	results := []scanResult{}
 	var errs []error
 	// Get all ClusterExtensions incompatible with next Y-stream
 	for _, obj := range ceList {
@@ -121,72 +308,86 @@ func (c *incompatibleOperatorController) getIncompatibleOperators() ([]string, e
 			continue
 		}
 		name := metaObj.GetName()
+		result := scanResult{Name: name, Decision: decisionCompatible}
 		logger := c.logger.WithValues("clusterextension", name)
 		rel, err := store.Deployed(name)
 		if errors.Is(err, driver.ErrNoDeployedReleases) {
 			logger.Info("Cluster Extension not yet deployed - will check again later")
+			result.Decision = decisionNotDeployed
+			results = append(results, result)
 			continue
 		}
 		if err != nil {
 			errMessage := fmt.Sprintf("error returning the last deployed release for %s", name)
 			logger.Info(errMessage)
 			errs = append(errs, errors.New(errMessage))
+			result.Decision = decisionUnknown
+			result.Error = errMessage
+			results = append(results, result)
 			continue
 		}
+		result.Bundle = rel.Labels[bundleNameKey]
+		result.Version = rel.Labels[bundleVersionKey]
+		result.Package = rel.Labels[packageNameKey]
 
 		if rel.Chart == nil || rel.Chart.Metadata == nil {
 			logger.Info("Chart or Chart.Metadata is nil")
+			results = append(results, result)
 			continue
 		}
 		if _, ok := rel.Chart.Metadata.Annotations["olm.properties"]; !ok {
 			logger.Info("Bundle has no properties")
+			results = append(results, result)
 			continue
 		}
 		logger = logger.WithValues("bundleName", rel.Labels[bundleNameKey])
-		props, err := propertyListFromPropertiesAnnotation(rel.Chart.Metadata.Annotations["olm.properties"])
+		props, err := compatibility.ParseProperties(rel.Chart.Metadata.Annotations["olm.properties"])
 		if err != nil {
 			err = fmt.Errorf("could not convert olm.properties: %v", err)
 			logger.Info(err.Error())
 			errs = append(errs, fmt.Errorf("error with cluster extension %s: error in bundle %s: %v", name, rel.Labels[bundleNameKey], err))
+			result.Decision = decisionUnknown
+			result.Error = err.Error()
+			results = append(results, result)
 			continue
 		}
-		numMaxOCPProps := 0
-		for _, p := range props {
-			if p.Type == maxOpenShiftVersionProperty {
-				numMaxOCPProps++
-				maxOCPVersion, err := utils.ToAllowedSemver(p.Value)
-				if err != nil {
-					err = fmt.Errorf("error converting to semver for version %s: %v", string(p.Value), err)
-					logger.Info(err.Error())
-					errs = append(errs, fmt.Errorf("error with cluster extension %s: error in bundle %s: %v", name, rel.Labels[bundleNameKey], err))
-					continue
-				}
-				if numMaxOCPProps > 1 {
-					err = fmt.Errorf("more than one %s found in bundle", maxOpenShiftVersionProperty)
-					logger.Info(err.Error())
-					errs = append(errs, fmt.Errorf("error with cluster extension %s: error in bundle %s: %v", name, rel.Labels[bundleNameKey], err))
-					continue
-				}
-				if maxOCPVersion != nil && !maxOCPVersion.GTE(*c.nextOCPMinorVersion) {
-					// Incompatible
-					incompatibleOperators = append(incompatibleOperators, fmt.Sprintf("bundle %q for ClusterExtension %q", rel.Labels[bundleNameKey], name))
-				}
-			}
+		result.MaxVersion = maxOpenShiftVersion(props)
+		reasons, err := compatibility.RunChecks(c.checks, props, targetVersion)
+		if err != nil {
+			logger.Info(err.Error())
+			errs = append(errs, fmt.Errorf("error with cluster extension %s: error in bundle %s: %v", name, rel.Labels[bundleNameKey], err))
+			result.Decision = decisionUnknown
+			result.Error = err.Error()
 		}
+		if len(reasons) > 0 {
+			// Incompatible
+			result.Decision = decisionIncompatible
+			result.Reasons = reasons
+		}
+		results = append(results, result)
 	}
 
-	// deterministic ordering
-	sort.Strings(incompatibleOperators)
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
 
-	return incompatibleOperators, errors.Join(errs...)
+	return results, errors.Join(errs...)
 }
 
-func propertyListFromPropertiesAnnotation(raw string) ([]property.Property, error) {
-	var props []property.Property
-	if err := json.Unmarshal([]byte(raw), &props); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal properties annotation: %w", err)
+func (c *incompatibleOperatorController) clusterExtensions(ctx context.Context) ([]runtime.Object, error) {
+	if c.listClusterExtensions != nil {
+		return c.listClusterExtensions(ctx)
 	}
-	return props, nil
+	return c.clusterExtensionClient.Informer().Lister().List(labels.NewSelector())
+}
+
+// maxOpenShiftVersion returns the major.minor olm.maxOpenShiftVersion of the bundle,
+// the first one when it is set more than once, or an empty string when it is not set
+// or invalid. Errors are reported by the checks.
+func maxOpenShiftVersion(props []property.Property) string {
+	v, _ := compatibility.MaxOpenShiftVersion(props)
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
 }
 
 func (c *incompatibleOperatorController) buildHelmStore(secretClient v1.SecretInterface) helm.Storage {
@@ -194,7 +395,23 @@ func (c *incompatibleOperatorController) buildHelmStore(secretClient v1.SecretIn
 	csConfig := storage.ChunkedSecretsConfig{Log: log}
 
 	return helm.Storage{
-		Driver: storage.NewChunkedSecrets(secretClient, "operator-controller", csConfig),
+		Driver: storage.NewChunkedSecrets(secretClient, c.helmStorageOwner, csConfig),
 		Log:    log,
 	}
 }
+
+// contextSecretClient reads secrets with ctx. The Helm storage drivers do not take a
+// context and read with context.Background, so this lets their reads be cancelled
+// along with the sync or request evaluating the releases.
+type contextSecretClient struct {
+	v1.SecretInterface
+	ctx context.Context
+}
+
+func (c contextSecretClient) Get(_ context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error) {
+	return c.SecretInterface.Get(c.ctx, name, opts)
+}
+
+func (c contextSecretClient) List(_ context.Context, opts metav1.ListOptions) (*corev1.SecretList, error) {
+	return c.SecretInterface.List(c.ctx, opts)
+}
@@ -0,0 +1,326 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	semver "github.com/blang/semver/v4"
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+	"github.com/openshift/cluster-olm-operator/pkg/compatibility"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	storage "github.com/operator-framework/helm-operator-plugins/pkg/storage"
+	ocv1 "github.com/operator-framework/operator-controller/api/v1"
+	"github.com/operator-framework/operator-registry/alpha/property"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	helmstorage "helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func maxOpenShiftVersionProp(value string) property.Property {
+	return property.Property{Type: compatibility.MaxOpenShiftVersionProperty, Value: json.RawMessage(value)}
+}
+
+func TestSummarizeOperators(t *testing.T) {
+	operators := []string{"a", "b", "c", "d", "e"}
+	for _, tc := range []struct {
+		name     string
+		limit    int
+		expected string
+	}{
+		{
+			name:     "below the cap",
+			limit:    10,
+			expected: "a,b,c,d,e",
+		},
+		{
+			name:     "at the cap",
+			limit:    5,
+			expected: "a,b,c,d,e",
+		},
+		{
+			name:     "exceeding the cap",
+			limit:    2,
+			expected: "a,b, and 3 more",
+		},
+		{
+			name:     "no cap",
+			limit:    0,
+			expected: "a,b,c,d,e",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := summarizeOperators(operators, tc.limit); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestIncompatibleOperatorsGracePeriod(t *testing.T) {
+	nextOCPMinorVersion := semver.MustParse("4.18.0")
+	incompatible := []incompatibleOperator{{Name: "foo", Bundle: "foo.v1"}}
+
+	for _, tc := range []struct {
+		name string
+		// results are returned by successive syncs, each one minute apart.
+		results        [][]incompatibleOperator
+		expectedStatus operatorv1.ConditionStatus
+	}{
+		{
+			name:           "transient incompatibility within the grace period",
+			results:        [][]incompatibleOperator{incompatible, incompatible, nil},
+			expectedStatus: operatorv1.ConditionTrue,
+		},
+		{
+			name:           "persistent incompatibility beyond the grace period",
+			results:        [][]incompatibleOperator{incompatible, incompatible, incompatible, incompatible},
+			expectedStatus: operatorv1.ConditionFalse,
+		},
+		{
+			name:    "grace period restarts once compatible",
+			results: [][]incompatibleOperator{incompatible, incompatible, nil, incompatible, incompatible},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClock := clocktesting.NewFakeClock(time.Now())
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+			var result []incompatibleOperator
+			c := &incompatibleOperatorController{
+				name:                 "test",
+				nextOCPMinorVersion:  &nextOCPMinorVersion,
+				clusterVersionLister: configv1listers.NewClusterVersionLister(indexer),
+				operatorClient:       operatorClient,
+				logger:               logr.Discard(),
+				gracePeriod:          3 * time.Minute,
+				clock:                fakeClock,
+				olmAnnotations:       &fakeAnnotationSetter{},
+				incompatibleOperatorsFunc: func(context.Context, semver.Version) ([]incompatibleOperator, error) {
+					return result, nil
+				},
+			}
+
+			for _, result = range tc.results {
+				if err := c.sync(context.Background(), nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				fakeClock.Step(time.Minute)
+			}
+
+			_, status, _, err := operatorClient.GetOperatorState()
+			if err != nil {
+				t.Fatalf("unexpected error getting operator state: %v", err)
+			}
+			cond := v1helpers.FindOperatorCondition(status.Conditions, typeIncompatibelOperatorsUpgradeable)
+			if tc.expectedStatus == "" {
+				if cond != nil && cond.Status == operatorv1.ConditionFalse {
+					t.Fatalf("expected condition %q not to be False, got %v", typeIncompatibelOperatorsUpgradeable, cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatalf("expected condition %q to be set", typeIncompatibelOperatorsUpgradeable)
+			}
+			if cond.Status != tc.expectedStatus {
+				t.Errorf("expected status %q, got %q", tc.expectedStatus, cond.Status)
+			}
+		})
+	}
+}
+
+func TestGetIncompatibleOperatorsHelmReleaseNamespace(t *testing.T) {
+	clusterExtension := &unstructured.Unstructured{}
+	clusterExtension.SetGroupVersionKind(ocv1.GroupVersion.WithKind("ClusterExtension"))
+	clusterExtension.SetName("foo")
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		ocv1.GroupVersion.WithResource("clusterextensions"): "ClusterExtensionList",
+	}, clusterExtension)
+	clusterExtensionClient := clients.NewClusterExtensionClient(dynamicClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go clusterExtensionClient.Informer().Informer().Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), clusterExtensionClient.Informer().Informer().HasSynced) {
+		t.Fatal("ClusterExtension informer did not sync")
+	}
+
+	for _, tc := range []struct {
+		name                 string
+		helmReleaseNamespace string
+		expectedOperators    int
+	}{
+		{
+			name:                 "release found in the operand namespace",
+			helmReleaseNamespace: "alternate-operator-controller",
+			expectedOperators:    1,
+		},
+		{
+			name:                 "release not found in another namespace",
+			helmReleaseNamespace: "openshift-operator-controller",
+			expectedOperators:    0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := kubefake.NewSimpleClientset()
+			c := &incompatibleOperatorController{
+				kubeclient:             kubeClient,
+				clusterExtensionClient: clusterExtensionClient,
+				checks:                 []compatibility.Check{compatibility.MaxOpenShiftVersionCheck},
+				logger:                 logr.Discard(),
+				helmReleaseNamespace:   tc.helmReleaseNamespace,
+				helmStorageOwner:       helmStorageOwner,
+			}
+
+			// operator-controller was deployed to a non-default namespace
+			writer := helmstorage.Init(storage.NewChunkedSecrets(kubeClient.CoreV1().Secrets("alternate-operator-controller"), helmStorageOwner, storage.ChunkedSecretsConfig{
+				ChunkSize: 1024 * 1024,
+				Log:       func(string, ...interface{}) {},
+			}))
+			if err := writer.Create(&release.Release{
+				Name:    "foo",
+				Version: 1,
+				Info:    &release.Info{Status: release.StatusDeployed},
+				Chart: &chart.Chart{Metadata: &chart.Metadata{Annotations: map[string]string{
+					"olm.properties": `[{"type":"olm.maxOpenShiftVersion","value":"4.17"}]`,
+				}}},
+			}); err != nil {
+				t.Fatalf("unexpected error creating release: %v", err)
+			}
+
+			operators, err := c.getIncompatibleOperators(context.Background(), semver.MustParse("4.18.0"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(operators) != tc.expectedOperators {
+				t.Errorf("expected %d incompatible operators, got %v", tc.expectedOperators, operators)
+			}
+		})
+	}
+}
+
+// contextRecordingSecretClient records the contexts secrets are read with.
+type contextRecordingSecretClient struct {
+	v1.SecretInterface
+	contexts []context.Context
+}
+
+func (c *contextRecordingSecretClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.Secret, error) {
+	c.contexts = append(c.contexts, ctx)
+	return c.SecretInterface.Get(ctx, name, opts)
+}
+
+func (c *contextRecordingSecretClient) List(ctx context.Context, opts metav1.ListOptions) (*corev1.SecretList, error) {
+	c.contexts = append(c.contexts, ctx)
+	return c.SecretInterface.List(ctx, opts)
+}
+
+func TestHelmStoreReadsWithContext(t *testing.T) {
+	type contextKey struct{}
+	kubeClient := kubefake.NewSimpleClientset()
+	recorder := &contextRecordingSecretClient{SecretInterface: kubeClient.CoreV1().Secrets("openshift-operator-controller")}
+	c := &incompatibleOperatorController{logger: logr.Discard(), helmStorageOwner: helmStorageOwner}
+	ctx := context.WithValue(context.Background(), contextKey{}, "request")
+
+	store := c.buildHelmStore(contextSecretClient{SecretInterface: recorder, ctx: ctx})
+	if _, err := store.Deployed("foo"); !errors.Is(err, driver.ErrNoDeployedReleases) {
+		t.Fatalf("expected no deployed releases, got %v", err)
+	}
+	if len(recorder.contexts) == 0 {
+		t.Fatal("expected the Helm store to read secrets")
+	}
+	for _, readCtx := range recorder.contexts {
+		if readCtx.Value(contextKey{}) != "request" {
+			t.Errorf("expected secrets to be read with the given context")
+		}
+	}
+}
+
+type fakeAnnotationSetter struct {
+	annotations map[string]string
+}
+
+func (f *fakeAnnotationSetter) SetAnnotation(_ context.Context, key, value string) error {
+	if f.annotations == nil {
+		f.annotations = map[string]string{}
+	}
+	if value == "" {
+		delete(f.annotations, key)
+		return nil
+	}
+	f.annotations[key] = value
+	return nil
+}
+
+func TestIncompatibleOperatorsAnnotation(t *testing.T) {
+	nextOCPMinorVersion := semver.MustParse("4.18.0")
+	operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+	annotations := &fakeAnnotationSetter{}
+
+	var result []incompatibleOperator
+	c := &incompatibleOperatorController{
+		name:                 "test",
+		nextOCPMinorVersion:  &nextOCPMinorVersion,
+		clusterVersionLister: configv1listers.NewClusterVersionLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		operatorClient:       operatorClient,
+		olmAnnotations:       annotations,
+		logger:               logr.Discard(),
+		clock:                clocktesting.NewFakeClock(time.Now()),
+		incompatibleOperatorsFunc: func(context.Context, semver.Version) ([]incompatibleOperator, error) {
+			return result, nil
+		},
+	}
+
+	result = []incompatibleOperator{{
+		Name:       "foo",
+		Bundle:     "foo.v1.2.0",
+		Version:    "1.2.0",
+		Package:    "foo",
+		MaxVersion: "4.17",
+		reasons:    []string{"olm.maxOpenShiftVersion=4.17"},
+	}}
+	if err := c.sync(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `[{"name":"foo","bundle":"foo.v1.2.0","version":"1.2.0","package":"foo","maxVersion":"4.17"}]`
+	if actual := annotations.annotations[incompatibleOperatorsAnnotation]; actual != expected {
+		t.Errorf("expected annotation %s, got %s", expected, actual)
+	}
+
+	result = nil
+	if err := c.sync(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual, ok := annotations.annotations[incompatibleOperatorsAnnotation]; ok {
+		t.Errorf("expected the annotation to be cleared, got %s", actual)
+	}
+}
+
+func TestMaxOpenShiftVersion(t *testing.T) {
+	if actual := maxOpenShiftVersion([]property.Property{maxOpenShiftVersionProp(`"4.17"`)}); actual != "4.17" {
+		t.Errorf("expected %q, got %q", "4.17", actual)
+	}
+	if actual := maxOpenShiftVersion([]property.Property{maxOpenShiftVersionProp(`"4.17"`), maxOpenShiftVersionProp(`"4.19"`)}); actual != "4.17" {
+		t.Errorf("expected the first version %q, got %q", "4.17", actual)
+	}
+	if actual := maxOpenShiftVersion(nil); actual != "" {
+		t.Errorf("expected no version, got %q", actual)
+	}
+}
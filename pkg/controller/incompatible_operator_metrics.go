@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// maxIncompatibleOperatorSeries caps the number of incompatible_operator series,
+// bounding the label cardinality on clusters with many incompatible extensions.
+// Operators beyond the cap, in ClusterExtension name order, are not published.
+const maxIncompatibleOperatorSeries = 100
+
+var incompatibleOperatorGauge = newIncompatibleOperatorGauge()
+
+func init() {
+	legacyregistry.MustRegister(incompatibleOperatorGauge)
+}
+
+func newIncompatibleOperatorGauge() *metrics.GaugeVec {
+	return metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "cluster_olm_operator",
+			Name:           "incompatible_operator",
+			Help:           "Set to 1 for each installed ClusterExtension bundle that blocks the cluster upgrade.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"extension", "bundle", "package"},
+	)
+}
+
+// incompatibleOperatorMetrics publishes one series per incompatible operator and
+// deletes the series of operators that are no longer incompatible.
+type incompatibleOperatorMetrics struct {
+	gauge     *metrics.GaugeVec
+	maxSeries int
+
+	lock      sync.Mutex
+	published map[[3]string]struct{}
+}
+
+func newIncompatibleOperatorMetrics(gauge *metrics.GaugeVec, maxSeries int) *incompatibleOperatorMetrics {
+	return &incompatibleOperatorMetrics{
+		gauge:     gauge,
+		maxSeries: maxSeries,
+		published: map[[3]string]struct{}{},
+	}
+}
+
+func (m *incompatibleOperatorMetrics) set(logger logr.Logger, operators []incompatibleOperator) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	sorted := make([]incompatibleOperator, len(operators))
+	copy(sorted, operators)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	if len(sorted) > m.maxSeries {
+		logger.Info("too many incompatible operators, not all are published as metrics", "published", m.maxSeries, "omitted", len(sorted)-m.maxSeries)
+		sorted = sorted[:m.maxSeries]
+	}
+
+	current := make(map[[3]string]struct{}, len(sorted))
+	for _, o := range sorted {
+		labels := [3]string{o.Name, o.Bundle, o.Package}
+		current[labels] = struct{}{}
+		m.gauge.WithLabelValues(labels[:]...).Set(1)
+	}
+	for labels := range m.published {
+		if _, ok := current[labels]; !ok {
+			m.gauge.DeleteLabelValues(labels[:]...)
+		}
+	}
+	m.published = current
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	semver "github.com/blang/semver/v4"
+	"github.com/go-logr/logr"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/cluster-olm-operator/pkg/compatibility"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/utils/clock"
+)
+
+func gatherIncompatibleOperatorSeries(t *testing.T, registry metrics.KubeRegistry) []string {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error gathering metrics: %v", err)
+	}
+	var series []string
+	for _, family := range families {
+		if family.GetName() != "cluster_olm_operator_incompatible_operator" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetName()+"="+label.GetValue())
+			}
+			series = append(series, strings.Join(labels, ","))
+		}
+	}
+	sort.Strings(series)
+	return series
+}
+
+func TestIncompatibleOperatorMetrics(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	gauge := newIncompatibleOperatorGauge()
+	registry.MustRegister(gauge)
+	m := newIncompatibleOperatorMetrics(gauge, 2)
+
+	m.set(logr.Discard(), []incompatibleOperator{
+		{Name: "b", Bundle: "b.v1", Package: "b-pkg"},
+		{Name: "a", Bundle: "a.v1", Package: "a-pkg"},
+	})
+	expected := []string{
+		"bundle=a.v1,extension=a,package=a-pkg",
+		"bundle=b.v1,extension=b,package=b-pkg",
+	}
+	if actual := gatherIncompatibleOperatorSeries(t, registry); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected series %v, got %v", expected, actual)
+	}
+
+	// a was upgraded to a compatible bundle
+	m.set(logr.Discard(), []incompatibleOperator{{Name: "b", Bundle: "b.v1", Package: "b-pkg"}})
+	expected = []string{"bundle=b.v1,extension=b,package=b-pkg"}
+	if actual := gatherIncompatibleOperatorSeries(t, registry); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected series %v, got %v", expected, actual)
+	}
+
+	m.set(logr.Discard(), nil)
+	if actual := gatherIncompatibleOperatorSeries(t, registry); len(actual) != 0 {
+		t.Fatalf("expected all series to be cleared, got %v", actual)
+	}
+}
+
+func TestIncompatibleOperatorMetricsCardinalityCap(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	gauge := newIncompatibleOperatorGauge()
+	registry.MustRegister(gauge)
+	m := newIncompatibleOperatorMetrics(gauge, 3)
+
+	var operators []incompatibleOperator
+	for i := 9; i >= 0; i-- {
+		operators = append(operators, incompatibleOperator{Name: fmt.Sprintf("ext-%d", i), Bundle: fmt.Sprintf("bundle-%d", i)})
+	}
+	m.set(logr.Discard(), operators)
+
+	expected := []string{
+		"bundle=bundle-0,extension=ext-0,package=",
+		"bundle=bundle-1,extension=ext-1,package=",
+		"bundle=bundle-2,extension=ext-2,package=",
+	}
+	if actual := gatherIncompatibleOperatorSeries(t, registry); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected series %v, got %v", expected, actual)
+	}
+}
+
+func TestIncompatibleOperatorMetricsKeptOnListFailure(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	gauge := newIncompatibleOperatorGauge()
+	registry.MustRegister(gauge)
+	nextOCPMinorVersion := semver.MustParse("4.18.0")
+
+	var listErr error
+	c := &incompatibleOperatorController{
+		name:                 "test",
+		nextOCPMinorVersion:  &nextOCPMinorVersion,
+		clusterVersionLister: configv1listers.NewClusterVersionLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		operatorClient:       v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil),
+		checks:               []compatibility.Check{compatibility.MaxOpenShiftVersionCheck},
+		logger:               logr.Discard(),
+		olmAnnotations:       &fakeAnnotationSetter{},
+		metrics:              newIncompatibleOperatorMetrics(gauge, 10),
+		clock:                clock.RealClock{},
+		incompatibleOperatorsFunc: func(context.Context, semver.Version) ([]incompatibleOperator, error) {
+			if listErr != nil {
+				return nil, listErr
+			}
+			return []incompatibleOperator{{Name: "foo", Bundle: "foo.v1", Package: "foo"}}, nil
+		},
+	}
+	expected := []string{"bundle=foo.v1,extension=foo,package=foo"}
+
+	if err := c.sync(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if actual := gatherIncompatibleOperatorSeries(t, registry); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected series %v, got %v", expected, actual)
+	}
+
+	listErr = errors.New("listing ClusterExtensions failed")
+	if err := c.sync(context.Background(), nil); err == nil {
+		t.Fatal("expected the list error")
+	}
+	if actual := gatherIncompatibleOperatorSeries(t, registry); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected series %v to be kept, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// bindHostPlaceholder is replaced in the operand manifests with the host operands
+	// listen on, e.g. --metrics-bind-address=${BIND_HOST}:8443.
+	bindHostPlaceholder = "${BIND_HOST}"
+
+	ipv4BindHost = "0.0.0.0"
+	// ipv6BindHost also accepts IPv4 connections, so it is used on dual-stack clusters.
+	ipv6BindHost = "[::]"
+)
+
+// replaceBindHostHook replaces the placeholder with the wildcard host matching the IP
+// families of the cluster networks: IPv4-only clusters listen on all IPv4 addresses,
+// IPv6-only and dual-stack clusters on all IPv6 addresses.
+func replaceBindHostHook(placeholder string, networkLister configv1listers.NetworkLister) deploymentcontroller.ManifestHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment []byte) ([]byte, error) {
+		network, err := networkLister.Get("cluster")
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("error getting networks.config.openshift.io/cluster: %w", err)
+		}
+		host, err := bindHost(network)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.ReplaceAll(string(deployment), placeholder, host)), nil
+	}
+}
+
+func bindHost(network *configv1.Network) (string, error) {
+	if network == nil {
+		return ipv4BindHost, nil
+	}
+	// the service networks are cloned so the CIDRs are not appended to the backing
+	// array of the cached object
+	cidrs := slices.Clone(network.Status.ServiceNetwork)
+	for _, entry := range network.Status.ClusterNetwork {
+		cidrs = append(cidrs, entry.CIDR)
+	}
+	// the status is only populated once the network operator has rolled out
+	if len(cidrs) == 0 {
+		cidrs = slices.Clone(network.Spec.ServiceNetwork)
+		for _, entry := range network.Spec.ClusterNetwork {
+			cidrs = append(cidrs, entry.CIDR)
+		}
+	}
+
+	hasIPv6 := false
+	for _, cidr := range cidrs {
+		ip, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return "", fmt.Errorf("error parsing cluster network CIDR %q: %w", cidr, err)
+		}
+		if ip.To4() == nil {
+			hasIPv6 = true
+		}
+	}
+	if hasIPv6 {
+		return ipv6BindHost, nil
+	}
+	return ipv4BindHost, nil
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func networkWithCIDRs(clusterNetworks []string, serviceNetworks ...string) *configv1.Network {
+	network := &configv1.Network{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.NetworkStatus{ServiceNetwork: serviceNetworks},
+	}
+	for _, cidr := range clusterNetworks {
+		network.Status.ClusterNetwork = append(network.Status.ClusterNetwork, configv1.ClusterNetworkEntry{CIDR: cidr})
+	}
+	return network
+}
+
+func TestReplaceBindHostHook(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		network  *configv1.Network
+		expected string
+	}{
+		{
+			name:     "IPv4 only",
+			network:  networkWithCIDRs([]string{"10.128.0.0/14"}, "172.30.0.0/16"),
+			expected: "--metrics-bind-address=0.0.0.0:8443",
+		},
+		{
+			name:     "IPv6 only",
+			network:  networkWithCIDRs([]string{"fd01::/48"}, "fd02::/112"),
+			expected: "--metrics-bind-address=[::]:8443",
+		},
+		{
+			name:     "dual-stack",
+			network:  networkWithCIDRs([]string{"10.128.0.0/14", "fd01::/48"}, "172.30.0.0/16", "fd02::/112"),
+			expected: "--metrics-bind-address=[::]:8443",
+		},
+		{
+			name: "status not yet populated",
+			network: &configv1.Network{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec:       configv1.NetworkSpec{ServiceNetwork: []string{"fd02::/112"}},
+			},
+			expected: "--metrics-bind-address=[::]:8443",
+		},
+		{
+			name:     "network not found",
+			expected: "--metrics-bind-address=0.0.0.0:8443",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.network != nil {
+				if err := indexer.Add(tc.network); err != nil {
+					t.Fatal(err)
+				}
+			}
+			hook := replaceBindHostHook(bindHostPlaceholder, configv1listers.NewNetworkLister(indexer))
+			actual, err := hook(nil, []byte("--metrics-bind-address=${BIND_HOST}:8443"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(actual) != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, string(actual))
+			}
+		})
+	}
+}
+
+func TestReplaceBindHostHookInvalidCIDR(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(networkWithCIDRs([]string{"not-a-cidr"})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replaceBindHostHook(bindHostPlaceholder, configv1listers.NewNetworkLister(indexer))(nil, []byte("${BIND_HOST}")); err == nil {
+		t.Fatal("expected an error for an invalid CIDR")
+	}
+}
+
+func TestReplaceBindHostHookDoesNotMutateLister(t *testing.T) {
+	// spare capacity lets appending to the service networks write into their backing array
+	serviceNetworks := make([]string, 1, 4)
+	serviceNetworks[0] = "172.30.0.0/16"
+	network := networkWithCIDRs([]string{"10.128.0.0/14", "fd01::/48"})
+	network.Status.ServiceNetwork = serviceNetworks
+	network.Spec.ServiceNetwork = serviceNetworks
+	expected := network.DeepCopy()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(network); err != nil {
+		t.Fatal(err)
+	}
+	lister := configv1listers.NewNetworkLister(indexer)
+	if _, err := replaceBindHostHook(bindHostPlaceholder, lister)(nil, []byte("${BIND_HOST}")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	actual, err := lister.Get("cluster")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected the lister object to be unchanged, got %v", actual)
+	}
+	if spare := serviceNetworks[:cap(serviceNetworks)][1:]; !reflect.DeepEqual(spare, make([]string, len(spare))) {
+		t.Errorf("expected the service networks backing array to be unchanged, got %v", spare)
+	}
+}
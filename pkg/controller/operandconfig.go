@@ -0,0 +1,597 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+// OperandConfigs maps a component, i.e. a subdirectory of the operand assets
+// such as "catalogd" or "operator-controller", to the overrides applied to
+// the Deployments and ClusterCatalogs rendered for that component.
+type OperandConfigs map[string]OperandConfig
+
+// OperandConfig holds administrator-provided overrides for the resources
+// of a single operand component.
+type OperandConfig struct {
+	// PriorityClassName is set on the pod template of the component's Deployments.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Volumes are added to the pod template of the component's Deployments.
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts are added to the named containers of the component's Deployments.
+	VolumeMounts []ContainerVolumeMount `json:"volumeMounts,omitempty"`
+
+	// ClusterCatalogs holds overrides for the component's managed ClusterCatalogs,
+	// keyed by ClusterCatalog name.
+	ClusterCatalogs map[string]ClusterCatalogConfig `json:"clusterCatalogs,omitempty"`
+
+	// Proxy overrides the cluster proxy settings injected into the component's containers.
+	Proxy *ProxyConfig `json:"proxy,omitempty"`
+
+	// Strategy, when set, replaces the update strategy of the component's Deployments.
+	Strategy *appsv1.DeploymentStrategy `json:"strategy,omitempty"`
+
+	// NodeSelector is merged into the node selector of the pod template of the
+	// component's Deployments, replacing the value of keys already set by the manifest.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are added to the pod template of the component's Deployments.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// TerminationGracePeriodSeconds, when set, replaces the termination grace period
+	// of the pod template of the component's Deployments.
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// PodAnnotations and PodLabels are merged into the pod template of the component's
+	// Deployments, e.g. so that monitoring discovers the operand pods, replacing the
+	// value of keys already set by the manifest.
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+	PodLabels      map[string]string `json:"podLabels,omitempty"`
+
+	// SecurityContexts override the security context of the named containers of the
+	// component's Deployments, e.g. to satisfy a stricter security policy.
+	SecurityContexts []ContainerSecurityContext `json:"securityContexts,omitempty"`
+}
+
+// ProxyConfig overrides the cluster-wide proxy for the containers of a component.
+type ProxyConfig struct {
+	// Disabled leaves the proxy environment of the component's containers unset.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// HTTPProxy, HTTPSProxy and NoProxy, when set, replace the corresponding
+	// settings of the cluster proxy.
+	HTTPProxy  string `json:"httpProxy,omitempty"`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	NoProxy    string `json:"noProxy,omitempty"`
+}
+
+// ClusterCatalogConfig holds overrides merged into a managed ClusterCatalog manifest.
+// They are enforced like the rest of the manifest, while labels added to the live
+// object by users are left alone.
+type ClusterCatalogConfig struct {
+	// Labels are merged into the labels of the ClusterCatalog.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Priority, when set, replaces spec.priority of the ClusterCatalog.
+	Priority *int32 `json:"priority,omitempty"`
+}
+
+// ContainerVolumeMount is a volume mount added to a single container.
+type ContainerVolumeMount struct {
+	// Container is the name of the container the volume is mounted into.
+	Container string `json:"container"`
+
+	corev1.VolumeMount `json:",inline"`
+}
+
+// ContainerSecurityContext holds the security context settings replaced in a single
+// container. Settings left unset keep the value of the manifest.
+type ContainerSecurityContext struct {
+	// Container is the name of the container the settings apply to.
+	Container string `json:"container"`
+
+	ReadOnlyRootFilesystem *bool                  `json:"readOnlyRootFilesystem,omitempty"`
+	RunAsUser              *int64                 `json:"runAsUser,omitempty"`
+	SeccompProfile         *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// LoadOperandConfigs reads and validates the operand configuration file at path,
+// rejecting the configuration of components other than the given ones.
+func LoadOperandConfigs(path string, components ...string) (OperandConfigs, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading operand config %q: %w", path, err)
+	}
+	var configs OperandConfigs
+	if err := yaml.UnmarshalStrict(data, &configs); err != nil {
+		return nil, fmt.Errorf("error parsing operand config %q: %w", path, err)
+	}
+	if err := configs.Validate(components...); err != nil {
+		return nil, fmt.Errorf("invalid operand config %q: %w", path, err)
+	}
+	return configs, nil
+}
+
+// Validate returns an aggregated error describing every invalid override, and every
+// component other than the given ones, such as a misspelled one.
+func (c OperandConfigs) Validate(components ...string) error {
+	var errs []error
+	known := sets.New(components...)
+	for component, config := range c {
+		if !known.Has(component) {
+			errs = append(errs, fmt.Errorf("unknown component %q, must be one of %q", component, sets.List(known)))
+			continue
+		}
+		if err := config.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", component, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c OperandConfig) Validate() error {
+	var errs []error
+	if c.PriorityClassName != "" {
+		if msgs := validation.IsDNS1123Subdomain(c.PriorityClassName); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("priorityClassName %q is invalid: %s", c.PriorityClassName, strings.Join(msgs, ", ")))
+		}
+	}
+	volumeNames := sets.New[string]()
+	for i, volume := range c.Volumes {
+		if msgs := validation.IsDNS1123Label(volume.Name); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("volumes[%d].name %q is invalid: %s", i, volume.Name, strings.Join(msgs, ", ")))
+		}
+		if volumeNames.Has(volume.Name) {
+			errs = append(errs, fmt.Errorf("volumes[%d].name %q is duplicated", i, volume.Name))
+		}
+		volumeNames.Insert(volume.Name)
+	}
+	mountPaths := map[string]sets.Set[string]{}
+	for i, mount := range c.VolumeMounts {
+		if mount.Container == "" {
+			errs = append(errs, fmt.Errorf("volumeMounts[%d].container is required", i))
+		}
+		if !volumeNames.Has(mount.Name) {
+			errs = append(errs, fmt.Errorf("volumeMounts[%d].name %q does not refer to a configured volume", i, mount.Name))
+		}
+		if !path.IsAbs(mount.MountPath) {
+			errs = append(errs, fmt.Errorf("volumeMounts[%d].mountPath %q must be an absolute path", i, mount.MountPath))
+		}
+		if mountPaths[mount.Container] == nil {
+			mountPaths[mount.Container] = sets.New[string]()
+		}
+		if mountPaths[mount.Container].Has(mount.MountPath) {
+			errs = append(errs, fmt.Errorf("volumeMounts[%d].mountPath %q is already mounted in container %q", i, mount.MountPath, mount.Container))
+		}
+		mountPaths[mount.Container].Insert(mount.MountPath)
+	}
+	for name, catalog := range c.ClusterCatalogs {
+		if err := catalog.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("clusterCatalogs[%s]: %w", name, err))
+		}
+	}
+	if c.Proxy != nil {
+		if err := c.Proxy.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("proxy: %w", err))
+		}
+	}
+	if c.Strategy != nil {
+		if err := validateDeploymentStrategy(c.Strategy); err != nil {
+			errs = append(errs, fmt.Errorf("strategy: %w", err))
+		}
+	}
+	for key, value := range c.NodeSelector {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("nodeSelector key %q is invalid: %s", key, strings.Join(msgs, ", ")))
+		}
+		if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("nodeSelector %q value %q is invalid: %s", key, value, strings.Join(msgs, ", ")))
+		}
+	}
+	for i, toleration := range c.Tolerations {
+		if err := validateToleration(toleration); err != nil {
+			errs = append(errs, fmt.Errorf("tolerations[%d]: %w", i, err))
+		}
+	}
+	if c.TerminationGracePeriodSeconds != nil && *c.TerminationGracePeriodSeconds < 0 {
+		errs = append(errs, fmt.Errorf("terminationGracePeriodSeconds %d must not be negative", *c.TerminationGracePeriodSeconds))
+	}
+	for key := range c.PodAnnotations {
+		if msgs := validation.IsQualifiedName(strings.ToLower(key)); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("podAnnotations key %q is invalid: %s", key, strings.Join(msgs, ", ")))
+		}
+	}
+	for key, value := range c.PodLabels {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("podLabels key %q is invalid: %s", key, strings.Join(msgs, ", ")))
+		}
+		if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("podLabels %q value %q is invalid: %s", key, value, strings.Join(msgs, ", ")))
+		}
+	}
+	securityContextContainers := sets.New[string]()
+	for i, securityContext := range c.SecurityContexts {
+		if securityContext.Container == "" {
+			errs = append(errs, fmt.Errorf("securityContexts[%d].container is required", i))
+		} else if securityContextContainers.Has(securityContext.Container) {
+			errs = append(errs, fmt.Errorf("securityContexts[%d].container %q is duplicated", i, securityContext.Container))
+		}
+		securityContextContainers.Insert(securityContext.Container)
+		if err := securityContext.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("securityContexts[%d]: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// allowedSeccompProfileTypes are the seccomp profile types an override may set;
+// an override may not leave a container unconfined.
+var allowedSeccompProfileTypes = sets.New(corev1.SeccompProfileTypeRuntimeDefault, corev1.SeccompProfileTypeLocalhost)
+
+func (c ContainerSecurityContext) Validate() error {
+	var errs []error
+	if c.RunAsUser != nil && (*c.RunAsUser < 0 || *c.RunAsUser > math.MaxInt32) {
+		errs = append(errs, fmt.Errorf("runAsUser %d must be between 0 and %d", *c.RunAsUser, math.MaxInt32))
+	}
+	if profile := c.SeccompProfile; profile != nil {
+		switch {
+		case !allowedSeccompProfileTypes.Has(profile.Type):
+			errs = append(errs, fmt.Errorf("seccompProfile.type %q is not supported, must be one of %q", profile.Type, sets.List(allowedSeccompProfileTypes)))
+		case profile.Type == corev1.SeccompProfileTypeLocalhost && ptr.Deref(profile.LocalhostProfile, "") == "":
+			errs = append(errs, fmt.Errorf("seccompProfile.localhostProfile is required for type %q", profile.Type))
+		case profile.Type != corev1.SeccompProfileTypeLocalhost && profile.LocalhostProfile != nil:
+			errs = append(errs, fmt.Errorf("seccompProfile.localhostProfile must not be set for type %q", profile.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateToleration applies the rules the API server enforces on pod tolerations.
+func validateToleration(toleration corev1.Toleration) error {
+	var errs []error
+	if toleration.Key != "" {
+		if msgs := validation.IsQualifiedName(toleration.Key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("key %q is invalid: %s", toleration.Key, strings.Join(msgs, ", ")))
+		}
+	}
+	switch toleration.Operator {
+	case corev1.TolerationOpExists:
+		if toleration.Value != "" {
+			errs = append(errs, fmt.Errorf("value must be empty when operator is %q", toleration.Operator))
+		}
+	case corev1.TolerationOpEqual, "":
+		if toleration.Key == "" {
+			errs = append(errs, errors.New("operator must be Exists when key is empty"))
+		}
+		if msgs := validation.IsValidLabelValue(toleration.Value); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("value %q is invalid: %s", toleration.Value, strings.Join(msgs, ", ")))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("operator %q must be %q or %q", toleration.Operator, corev1.TolerationOpExists, corev1.TolerationOpEqual))
+	}
+	switch toleration.Effect {
+	case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		errs = append(errs, fmt.Errorf("effect %q must be one of %q, %q or %q", toleration.Effect, corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute))
+	}
+	if toleration.TolerationSeconds != nil && toleration.Effect != corev1.TaintEffectNoExecute {
+		errs = append(errs, fmt.Errorf("tolerationSeconds can only be set when effect is %q", corev1.TaintEffectNoExecute))
+	}
+	return errors.Join(errs...)
+}
+
+func validateDeploymentStrategy(strategy *appsv1.DeploymentStrategy) error {
+	switch strategy.Type {
+	case appsv1.RecreateDeploymentStrategyType:
+		if strategy.RollingUpdate != nil {
+			return fmt.Errorf("rollingUpdate cannot be set when type is %q", strategy.Type)
+		}
+		return nil
+	case appsv1.RollingUpdateDeploymentStrategyType:
+	default:
+		return fmt.Errorf("type %q must be %q or %q", strategy.Type, appsv1.RecreateDeploymentStrategyType, appsv1.RollingUpdateDeploymentStrategyType)
+	}
+	if strategy.RollingUpdate == nil {
+		return nil
+	}
+
+	// the values are checked against a nominal replica count of 100 so that
+	// percentages and absolute numbers are validated alike
+	var errs []error
+	var maxSurge, maxUnavailable int
+	for _, field := range []struct {
+		name  string
+		value *intstr.IntOrString
+		into  *int
+	}{
+		{"maxSurge", strategy.RollingUpdate.MaxSurge, &maxSurge},
+		{"maxUnavailable", strategy.RollingUpdate.MaxUnavailable, &maxUnavailable},
+	} {
+		if field.value == nil {
+			// unset values default to 25%
+			*field.into = 1
+			continue
+		}
+		scaled, err := intstr.GetScaledValueFromIntOrPercent(field.value, 100, true)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rollingUpdate.%s %q is invalid: %w", field.name, field.value.String(), err))
+			continue
+		}
+		if scaled < 0 {
+			errs = append(errs, fmt.Errorf("rollingUpdate.%s %q must not be negative", field.name, field.value.String()))
+			continue
+		}
+		*field.into = scaled
+	}
+	if len(errs) == 0 && maxSurge == 0 && maxUnavailable == 0 {
+		errs = append(errs, errors.New("rollingUpdate.maxSurge and rollingUpdate.maxUnavailable cannot both be 0"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c ProxyConfig) Validate() error {
+	if c.Disabled {
+		if c.HTTPProxy != "" || c.HTTPSProxy != "" || c.NoProxy != "" {
+			return errors.New("httpProxy, httpsProxy and noProxy cannot be set when the proxy is disabled")
+		}
+		return nil
+	}
+	var errs []error
+	for _, proxy := range []struct{ field, value string }{
+		{"httpProxy", c.HTTPProxy},
+		{"httpsProxy", c.HTTPSProxy},
+	} {
+		if proxy.value == "" {
+			continue
+		}
+		if u, err := url.Parse(proxy.value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s %q must be an http or https URL", proxy.field, proxy.value))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// apply returns the proxy environment with the overrides applied.
+func (c *ProxyConfig) apply(httpsProxy, httpProxy, noProxy string) (string, string, string) {
+	if c == nil {
+		return httpsProxy, httpProxy, noProxy
+	}
+	if c.HTTPSProxy != "" {
+		httpsProxy = c.HTTPSProxy
+	}
+	if c.HTTPProxy != "" {
+		httpProxy = c.HTTPProxy
+	}
+	if c.NoProxy != "" {
+		noProxy = c.NoProxy
+	}
+	return httpsProxy, httpProxy, noProxy
+}
+
+func (c ClusterCatalogConfig) Validate() error {
+	var errs []error
+	for key, value := range c.Labels {
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("label key %q is invalid: %s", key, strings.Join(msgs, ", ")))
+		}
+		if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("label %q value %q is invalid: %s", key, value, strings.Join(msgs, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyTo merges the overrides into the ClusterCatalog manifest.
+func (c ClusterCatalogConfig) applyTo(manifest *unstructured.Unstructured) error {
+	if len(c.Labels) > 0 {
+		labels := manifest.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for key, value := range c.Labels {
+			labels[key] = value
+		}
+		manifest.SetLabels(labels)
+	}
+	if c.Priority != nil {
+		if err := unstructured.SetNestedField(manifest.Object, int64(*c.Priority), "spec", "priority"); err != nil {
+			return fmt.Errorf("setting spec.priority: %w", err)
+		}
+	}
+	return nil
+}
+
+// deploymentHooks returns the hooks applying this configuration to a Deployment.
+func (c OperandConfig) deploymentHooks() []deploymentcontroller.DeploymentHookFunc {
+	var hooks []deploymentcontroller.DeploymentHookFunc
+	if c.PriorityClassName != "" {
+		hooks = append(hooks, priorityClassHook(c.PriorityClassName))
+	}
+	if len(c.Volumes) > 0 || len(c.VolumeMounts) > 0 {
+		hooks = append(hooks, volumesHook(c.Volumes, c.VolumeMounts))
+	}
+	if c.Strategy != nil {
+		hooks = append(hooks, strategyHook(*c.Strategy))
+	}
+	if len(c.NodeSelector) > 0 || len(c.Tolerations) > 0 {
+		hooks = append(hooks, schedulingHook(c.NodeSelector, c.Tolerations))
+	}
+	if c.TerminationGracePeriodSeconds != nil {
+		hooks = append(hooks, terminationGracePeriodHook(*c.TerminationGracePeriodSeconds))
+	}
+	if len(c.PodAnnotations) > 0 || len(c.PodLabels) > 0 {
+		hooks = append(hooks, podMetadataHook(c.PodAnnotations, c.PodLabels))
+	}
+	if len(c.SecurityContexts) > 0 {
+		hooks = append(hooks, securityContextsHook(c.SecurityContexts))
+	}
+	return hooks
+}
+
+// securityContextsHook replaces the configured security context settings of the
+// named containers, failing when a container is not found.
+func securityContextsHook(securityContexts []ContainerSecurityContext) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		var errs []error
+		for _, override := range securityContexts {
+			container := findContainer(&deployment.Spec.Template.Spec, override.Container)
+			if container == nil {
+				errs = append(errs, fmt.Errorf("container %q not found in Deployment %q", override.Container, deployment.Name))
+				continue
+			}
+			if container.SecurityContext == nil {
+				container.SecurityContext = &corev1.SecurityContext{}
+			}
+			if override.ReadOnlyRootFilesystem != nil {
+				container.SecurityContext.ReadOnlyRootFilesystem = ptr.To(*override.ReadOnlyRootFilesystem)
+			}
+			if override.RunAsUser != nil {
+				container.SecurityContext.RunAsUser = ptr.To(*override.RunAsUser)
+			}
+			if override.SeccompProfile != nil {
+				container.SecurityContext.SeccompProfile = override.SeccompProfile.DeepCopy()
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// podMetadataHook merges the annotations and labels into the pod template.
+func podMetadataHook(annotations, labels map[string]string) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		template := &deployment.Spec.Template
+		if len(annotations) > 0 && template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		for key, value := range annotations {
+			template.Annotations[key] = value
+		}
+		if len(labels) > 0 && template.Labels == nil {
+			template.Labels = map[string]string{}
+		}
+		for key, value := range labels {
+			template.Labels[key] = value
+		}
+		return nil
+	}
+}
+
+func terminationGracePeriodHook(seconds int64) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = ptr.To(seconds)
+		return nil
+	}
+}
+
+func strategyHook(strategy appsv1.DeploymentStrategy) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		deployment.Spec.Strategy = *strategy.DeepCopy()
+		return nil
+	}
+}
+
+func priorityClassHook(priorityClassName string) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		deployment.Spec.Template.Spec.PriorityClassName = priorityClassName
+		return nil
+	}
+}
+
+// schedulingHook merges the node selector into the pod template and adds the
+// tolerations it does not already have.
+func schedulingHook(nodeSelector map[string]string, tolerations []corev1.Toleration) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		podSpec := &deployment.Spec.Template.Spec
+		if len(nodeSelector) > 0 && podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = map[string]string{}
+		}
+		for key, value := range nodeSelector {
+			podSpec.NodeSelector[key] = value
+		}
+		for _, toleration := range tolerations {
+			exists := false
+			for _, existing := range podSpec.Tolerations {
+				if existing.MatchToleration(&toleration) && reflect.DeepEqual(existing.TolerationSeconds, toleration.TolerationSeconds) {
+					exists = true
+					break
+				}
+			}
+			if !exists {
+				podSpec.Tolerations = append(podSpec.Tolerations, toleration)
+			}
+		}
+		return nil
+	}
+}
+
+// volumesHook adds the volumes to the pod template and the mounts to their containers,
+// failing rather than overriding a volume or mount path already set by the manifest.
+func volumesHook(volumes []corev1.Volume, mounts []ContainerVolumeMount) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		var errs []error
+		podSpec := &deployment.Spec.Template.Spec
+		existingVolumes := sets.New[string]()
+		for _, existing := range podSpec.Volumes {
+			existingVolumes.Insert(existing.Name)
+		}
+		for _, volume := range volumes {
+			if existingVolumes.Has(volume.Name) {
+				errs = append(errs, fmt.Errorf("volume %q already exists in Deployment %q", volume.Name, deployment.Name))
+				continue
+			}
+			podSpec.Volumes = append(podSpec.Volumes, volume)
+		}
+
+		for _, mount := range mounts {
+			container := findContainer(podSpec, mount.Container)
+			if container == nil {
+				errs = append(errs, fmt.Errorf("container %q not found in Deployment %q", mount.Container, deployment.Name))
+				continue
+			}
+			conflict := false
+			for _, existing := range container.VolumeMounts {
+				if existing.MountPath == mount.MountPath {
+					errs = append(errs, fmt.Errorf("mountPath %q of volume %q conflicts with volume %q in container %q", mount.MountPath, mount.Name, existing.Name, container.Name))
+					conflict = true
+					break
+				}
+			}
+			if !conflict {
+				container.VolumeMounts = append(container.VolumeMounts, mount.VolumeMount)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func findContainer(podSpec *corev1.PodSpec, name string) *corev1.Container {
+	for i := range podSpec.InitContainers {
+		if podSpec.InitContainers[i].Name == name {
+			return &podSpec.InitContainers[i]
+		}
+	}
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == name {
+			return &podSpec.Containers[i]
+		}
+	}
+	return nil
+}
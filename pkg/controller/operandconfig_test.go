@@ -0,0 +1,989 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+func writeOperandConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "operand-config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing operand config: %v", err)
+	}
+	return path
+}
+
+func applyDeploymentHooks(t *testing.T, config OperandConfig, deployment *appsv1.Deployment) {
+	t.Helper()
+	for _, hook := range config.deploymentHooks() {
+		if err := hook(nil, deployment); err != nil {
+			t.Fatalf("unexpected error applying hook: %v", err)
+		}
+	}
+}
+
+func TestLoadOperandConfigs(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expected      OperandConfigs
+		expectedError string
+	}{
+		{
+			name: "valid priority class",
+			content: `catalogd:
+  priorityClassName: system-cluster-critical
+`,
+			expected: OperandConfigs{
+				"catalogd": {PriorityClassName: "system-cluster-critical"},
+			},
+		},
+		{
+			name: "invalid priority class",
+			content: `operator-controller:
+  priorityClassName: Not_Valid
+`,
+			expectedError: `operator-controller: priorityClassName "Not_Valid" is invalid`,
+		},
+		{
+			name: "unknown field",
+			content: `catalogd:
+  priorityClass: system-cluster-critical
+`,
+			expectedError: "unknown field",
+		},
+		{
+			name: "unknown component",
+			content: `operator-controler:
+  priorityClassName: system-cluster-critical
+`,
+			expectedError: `unknown component "operator-controler", must be one of ["catalogd" "operator-controller"]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			configs, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expected, configs) {
+				t.Errorf("expected configs %+v, got %+v", tc.expected, configs)
+			}
+		})
+	}
+}
+
+func TestPriorityClassHook(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "manager"}},
+				},
+			},
+		},
+	}
+
+	applyDeploymentHooks(t, OperandConfig{PriorityClassName: "system-cluster-critical"}, deployment)
+
+	if deployment.Spec.Template.Spec.PriorityClassName != "system-cluster-critical" {
+		t.Errorf("expected priorityClassName %q, got %q", "system-cluster-critical", deployment.Spec.Template.Spec.PriorityClassName)
+	}
+}
+
+func TestOperandConfigNoOverrides(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+	applyDeploymentHooks(t, OperandConfig{}, deployment)
+	if deployment.Spec.Template.Spec.PriorityClassName != "" {
+		t.Errorf("expected priorityClassName to be unset, got %q", deployment.Spec.Template.Spec.PriorityClassName)
+	}
+}
+
+func TestLoadOperandConfigsVolumes(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "valid volume and mount",
+			content: `operator-controller:
+  volumes:
+  - name: registry-auth
+    secret:
+      secretName: registry-auth
+  volumeMounts:
+  - container: manager
+    name: registry-auth
+    mountPath: /etc/registry-auth
+    readOnly: true
+`,
+		},
+		{
+			name: "mount of an unknown volume",
+			content: `operator-controller:
+  volumeMounts:
+  - container: manager
+    name: registry-auth
+    mountPath: /etc/registry-auth
+`,
+			expectedError: `volumeMounts[0].name "registry-auth" does not refer to a configured volume`,
+		},
+		{
+			name: "relative mount path",
+			content: `operator-controller:
+  volumes:
+  - name: registry-auth
+    emptyDir: {}
+  volumeMounts:
+  - container: manager
+    name: registry-auth
+    mountPath: etc/registry-auth
+`,
+			expectedError: `volumeMounts[0].mountPath "etc/registry-auth" must be an absolute path`,
+		},
+		{
+			name: "conflicting mount paths",
+			content: `operator-controller:
+  volumes:
+  - name: a
+    emptyDir: {}
+  - name: b
+    emptyDir: {}
+  volumeMounts:
+  - container: manager
+    name: a
+    mountPath: /etc/auth
+  - container: manager
+    name: b
+    mountPath: /etc/auth
+`,
+			expectedError: `volumeMounts[1].mountPath "/etc/auth" is already mounted in container "manager"`,
+		},
+		{
+			name: "invalid volume name",
+			content: `catalogd:
+  volumes:
+  - name: Not_Valid
+    emptyDir: {}
+`,
+			expectedError: `volumes[0].name "Not_Valid" is invalid`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestVolumesHook(t *testing.T) {
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Volumes: []corev1.Volume{{Name: "cache"}},
+						Containers: []corev1.Container{
+							{Name: "manager", VolumeMounts: []corev1.VolumeMount{{Name: "cache", MountPath: "/var/cache"}}},
+							{Name: "kube-rbac-proxy"},
+						},
+					},
+				},
+			},
+		}
+	}
+	volume := corev1.Volume{Name: "registry-auth", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "registry-auth"}}}
+
+	t.Run("injects into the named container", func(t *testing.T) {
+		deployment := newDeployment()
+		mount := corev1.VolumeMount{Name: "registry-auth", MountPath: "/etc/registry-auth", ReadOnly: true}
+		applyDeploymentHooks(t, OperandConfig{
+			Volumes:      []corev1.Volume{volume},
+			VolumeMounts: []ContainerVolumeMount{{Container: "manager", VolumeMount: mount}},
+		}, deployment)
+
+		podSpec := deployment.Spec.Template.Spec
+		if !reflect.DeepEqual([]corev1.Volume{{Name: "cache"}, volume}, podSpec.Volumes) {
+			t.Errorf("unexpected volumes: %+v", podSpec.Volumes)
+		}
+		if !reflect.DeepEqual([]corev1.VolumeMount{{Name: "cache", MountPath: "/var/cache"}, mount}, podSpec.Containers[0].VolumeMounts) {
+			t.Errorf("unexpected volume mounts for container %q: %+v", "manager", podSpec.Containers[0].VolumeMounts)
+		}
+		if len(podSpec.Containers[1].VolumeMounts) != 0 {
+			t.Errorf("expected no volume mounts for container %q, got: %+v", "kube-rbac-proxy", podSpec.Containers[1].VolumeMounts)
+		}
+	})
+
+	for _, tc := range []struct {
+		name          string
+		config        OperandConfig
+		expectedError string
+	}{
+		{
+			name: "mount path conflicts with the manifest",
+			config: OperandConfig{
+				Volumes:      []corev1.Volume{volume},
+				VolumeMounts: []ContainerVolumeMount{{Container: "manager", VolumeMount: corev1.VolumeMount{Name: "registry-auth", MountPath: "/var/cache"}}},
+			},
+			expectedError: `mountPath "/var/cache" of volume "registry-auth" conflicts with volume "cache" in container "manager"`,
+		},
+		{
+			name:          "volume already in the manifest",
+			config:        OperandConfig{Volumes: []corev1.Volume{{Name: "cache"}}},
+			expectedError: `volume "cache" already exists`,
+		},
+		{
+			name: "unknown container",
+			config: OperandConfig{
+				Volumes:      []corev1.Volume{volume},
+				VolumeMounts: []ContainerVolumeMount{{Container: "missing", VolumeMount: corev1.VolumeMount{Name: "registry-auth", MountPath: "/etc/registry-auth"}}},
+			},
+			expectedError: `container "missing" not found`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var errs []string
+			for _, hook := range tc.config.deploymentHooks() {
+				if err := hook(nil, newDeployment()); err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+			if !strings.Contains(strings.Join(errs, "\n"), tc.expectedError) {
+				t.Errorf("expected error containing %q, got %v", tc.expectedError, errs)
+			}
+		})
+	}
+}
+
+func TestClusterCatalogConfigApplyTo(t *testing.T) {
+	newManifest := func(t *testing.T) *unstructured.Unstructured {
+		manifest := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(requiredYAML), &manifest.Object); err != nil {
+			t.Fatal(err)
+		}
+		manifest.SetLabels(map[string]string{"manifest": "label"})
+		return manifest
+	}
+
+	t.Run("labels merged and priority set", func(t *testing.T) {
+		manifest := newManifest(t)
+		config := ClusterCatalogConfig{
+			Labels:   map[string]string{"policy.example.com/selected": "true"},
+			Priority: ptr.To[int32](-100),
+		}
+		if err := config.applyTo(manifest); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expectedLabels := map[string]string{"manifest": "label", "policy.example.com/selected": "true"}
+		if !reflect.DeepEqual(expectedLabels, manifest.GetLabels()) {
+			t.Errorf("expected labels %v, got %v", expectedLabels, manifest.GetLabels())
+		}
+		priority, found, err := unstructured.NestedInt64(manifest.Object, "spec", "priority")
+		if err != nil || !found || priority != -100 {
+			t.Errorf("expected spec.priority -100, got %d (found: %v, err: %v)", priority, found, err)
+		}
+
+		data, err := manifest.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		live := manifest.DeepCopy()
+		labels := live.GetLabels()
+		labels["user"] = "label"
+		live.SetLabels(labels)
+		needsUpdate, err := unstructuredShouldUpdateFunc()(data, live)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if needsUpdate {
+			t.Error("expected user-added labels not to require an update")
+		}
+
+		live.SetLabels(map[string]string{"manifest": "label", "user": "label"})
+		needsUpdate, err = unstructuredShouldUpdateFunc()(data, live)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !needsUpdate {
+			t.Error("expected a missing configured label to require an update")
+		}
+	})
+
+	t.Run("no overrides", func(t *testing.T) {
+		manifest := newManifest(t)
+		if err := (ClusterCatalogConfig{}).applyTo(manifest); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(newManifest(t), manifest) {
+			t.Errorf("expected manifest to be unchanged, got %v", manifest.Object)
+		}
+	})
+}
+
+func TestLoadOperandConfigsClusterCatalogs(t *testing.T) {
+	configs, err := LoadOperandConfigs(writeOperandConfig(t, `catalogd:
+  clusterCatalogs:
+    openshift-certified-operators:
+      labels:
+        policy.example.com/selected: "true"
+      priority: 100
+`), "catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := OperandConfigs{
+		"catalogd": {
+			ClusterCatalogs: map[string]ClusterCatalogConfig{
+				"openshift-certified-operators": {
+					Labels:   map[string]string{"policy.example.com/selected": "true"},
+					Priority: ptr.To[int32](100),
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v, got %+v", expected, configs)
+	}
+
+	_, err = LoadOperandConfigs(writeOperandConfig(t, `catalogd:
+  clusterCatalogs:
+    openshift-certified-operators:
+      labels:
+        "not valid": "true"
+`), "catalogd", "operator-controller")
+	if err == nil || !strings.Contains(err.Error(), `clusterCatalogs[openshift-certified-operators]: label key "not valid" is invalid`) {
+		t.Errorf("expected an invalid label key error, got %v", err)
+	}
+}
+
+func TestLoadOperandConfigsProxy(t *testing.T) {
+	configs, err := LoadOperandConfigs(writeOperandConfig(t, `catalogd:
+  proxy:
+    noProxy: .cluster.local,registry.example.com
+operator-controller:
+  proxy:
+    disabled: true
+`), "catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := OperandConfigs{
+		"catalogd":            {Proxy: &ProxyConfig{NoProxy: ".cluster.local,registry.example.com"}},
+		"operator-controller": {Proxy: &ProxyConfig{Disabled: true}},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v, got %+v", expected, configs)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "overrides on a disabled proxy",
+			content: `catalogd:
+  proxy:
+    disabled: true
+    httpProxy: http://proxy.example.com:3128
+`,
+			expectedError: "catalogd: proxy: httpProxy, httpsProxy and noProxy cannot be set when the proxy is disabled",
+		},
+		{
+			name: "invalid proxy URL",
+			content: `catalogd:
+  proxy:
+    httpsProxy: proxy.example.com
+`,
+			expectedError: `catalogd: proxy: httpsProxy "proxy.example.com" must be an http or https URL`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadOperandConfigsStrategy(t *testing.T) {
+	configs, err := LoadOperandConfigs(writeOperandConfig(t, `catalogd:
+  strategy:
+    type: Recreate
+operator-controller:
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 0
+      maxUnavailable: 50%
+`), "catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := OperandConfigs{
+		"catalogd": {Strategy: &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}},
+		"operator-controller": {Strategy: &appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxSurge:       ptr.To(intstr.FromInt32(0)),
+				MaxUnavailable: ptr.To(intstr.FromString("50%")),
+			},
+		}},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v, got %+v", expected, configs)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "unknown type",
+			content: `catalogd:
+  strategy:
+    type: BlueGreen
+`,
+			expectedError: `catalogd: strategy: type "BlueGreen" must be "Recreate" or "RollingUpdate"`,
+		},
+		{
+			name: "rolling update parameters with Recreate",
+			content: `catalogd:
+  strategy:
+    type: Recreate
+    rollingUpdate:
+      maxSurge: 1
+`,
+			expectedError: `catalogd: strategy: rollingUpdate cannot be set when type is "Recreate"`,
+		},
+		{
+			name: "no progress possible",
+			content: `catalogd:
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 0%
+      maxUnavailable: 0
+`,
+			expectedError: "catalogd: strategy: rollingUpdate.maxSurge and rollingUpdate.maxUnavailable cannot both be 0",
+		},
+		{
+			name: "invalid percentage",
+			content: `catalogd:
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: half
+`,
+			expectedError: `catalogd: strategy: rollingUpdate.maxUnavailable "half" is invalid`,
+		},
+		{
+			name: "negative value",
+			content: `catalogd:
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: -1
+`,
+			expectedError: `catalogd: strategy: rollingUpdate.maxSurge "-1" must not be negative`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestStrategyHook(t *testing.T) {
+	manifestStrategy := appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxSurge:       ptr.To(intstr.FromString("25%")),
+			MaxUnavailable: ptr.To(intstr.FromString("25%")),
+		},
+	}
+	for _, tc := range []struct {
+		name     string
+		strategy appsv1.DeploymentStrategy
+	}{
+		{
+			name:     "Recreate",
+			strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+		},
+		{
+			name: "custom RollingUpdate parameters",
+			strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxSurge:       ptr.To(intstr.FromInt32(0)),
+					MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Strategy: *manifestStrategy.DeepCopy()}}
+			applyDeploymentHooks(t, OperandConfig{Strategy: &tc.strategy}, deployment)
+			if !reflect.DeepEqual(tc.strategy, deployment.Spec.Strategy) {
+				t.Errorf("expected strategy %+v, got %+v", tc.strategy, deployment.Spec.Strategy)
+			}
+		})
+	}
+}
+
+func TestLoadOperandConfigsScheduling(t *testing.T) {
+	configs, err := LoadOperandConfigs(writeOperandConfig(t, `catalogd:
+  nodeSelector:
+    node-role.kubernetes.io/infra: ""
+  tolerations:
+  - key: node-role.kubernetes.io/infra
+    operator: Exists
+    effect: NoSchedule
+`), "catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := OperandConfigs{
+		"catalogd": {
+			NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+			Tolerations: []corev1.Toleration{
+				{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v, got %+v", expected, configs)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "invalid node selector key",
+			content: `catalogd:
+  nodeSelector:
+    "not valid": "true"
+`,
+			expectedError: `catalogd: nodeSelector key "not valid" is invalid`,
+		},
+		{
+			name: "value with the Exists operator",
+			content: `catalogd:
+  tolerations:
+  - key: infra
+    operator: Exists
+    value: "true"
+`,
+			expectedError: `catalogd: tolerations[0]: value must be empty when operator is "Exists"`,
+		},
+		{
+			name: "unknown effect",
+			content: `catalogd:
+  tolerations:
+  - key: infra
+    value: "true"
+    effect: NoRun
+`,
+			expectedError: `catalogd: tolerations[0]: effect "NoRun" must be one of`,
+		},
+		{
+			name: "tolerationSeconds without NoExecute",
+			content: `catalogd:
+  tolerations:
+  - key: infra
+    operator: Exists
+    effect: NoSchedule
+    tolerationSeconds: 60
+`,
+			expectedError: `catalogd: tolerations[0]: tolerationSeconds can only be set when effect is "NoExecute"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestSchedulingHook(t *testing.T) {
+	masterToleration := corev1.Toleration{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	infraToleration := corev1.Toleration{Key: "node-role.kubernetes.io/infra", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	deployment := &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{"kubernetes.io/os": "linux", "node-role.kubernetes.io/master": ""},
+					Tolerations:  []corev1.Toleration{masterToleration},
+					Containers:   []corev1.Container{{Name: "manager"}},
+				},
+			},
+		},
+	}
+
+	applyDeploymentHooks(t, OperandConfig{
+		NodeSelector: map[string]string{"node-role.kubernetes.io/master": "replaced", "node-role.kubernetes.io/infra": ""},
+		Tolerations:  []corev1.Toleration{masterToleration, infraToleration},
+	}, deployment)
+
+	expectedNodeSelector := map[string]string{
+		"kubernetes.io/os":               "linux",
+		"node-role.kubernetes.io/master": "replaced",
+		"node-role.kubernetes.io/infra":  "",
+	}
+	if !reflect.DeepEqual(expectedNodeSelector, deployment.Spec.Template.Spec.NodeSelector) {
+		t.Errorf("expected nodeSelector %v, got %v", expectedNodeSelector, deployment.Spec.Template.Spec.NodeSelector)
+	}
+	expectedTolerations := []corev1.Toleration{masterToleration, infraToleration}
+	if !reflect.DeepEqual(expectedTolerations, deployment.Spec.Template.Spec.Tolerations) {
+		t.Errorf("expected tolerations %v, got %v", expectedTolerations, deployment.Spec.Template.Spec.Tolerations)
+	}
+}
+
+func TestLoadOperandConfigsTerminationGracePeriod(t *testing.T) {
+	configs, err := LoadOperandConfigs(writeOperandConfig(t, `catalogd:
+  terminationGracePeriodSeconds: 120
+operator-controller:
+  terminationGracePeriodSeconds: 0
+`), "catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := OperandConfigs{
+		"catalogd":            {TerminationGracePeriodSeconds: ptr.To[int64](120)},
+		"operator-controller": {TerminationGracePeriodSeconds: ptr.To[int64](0)},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v, got %+v", expected, configs)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "negative",
+			content: `catalogd:
+  terminationGracePeriodSeconds: -1
+`,
+			expectedError: "catalogd: terminationGracePeriodSeconds -1 must not be negative",
+		},
+		{
+			name: "not an integer",
+			content: `catalogd:
+  terminationGracePeriodSeconds: 1.5
+`,
+			expectedError: "terminationGracePeriodSeconds",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestTerminationGracePeriodHook(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		manifest *int64
+		config   *int64
+		expected *int64
+	}{
+		{
+			name:     "custom grace period replaces the manifest value",
+			manifest: ptr.To[int64](10),
+			config:   ptr.To[int64](300),
+			expected: ptr.To[int64](300),
+		},
+		{
+			name:     "grace period set when the manifest has none",
+			config:   ptr.To[int64](0),
+			expected: ptr.To[int64](0),
+		},
+		{
+			name:     "manifest value kept without an override",
+			manifest: ptr.To[int64](10),
+			expected: ptr.To[int64](10),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{}
+			deployment.Spec.Template.Spec.TerminationGracePeriodSeconds = tc.manifest
+			applyDeploymentHooks(t, OperandConfig{TerminationGracePeriodSeconds: tc.config}, deployment)
+			if !reflect.DeepEqual(tc.expected, deployment.Spec.Template.Spec.TerminationGracePeriodSeconds) {
+				t.Errorf("expected terminationGracePeriodSeconds %v, got %v", ptr.Deref(tc.expected, -1), ptr.Deref(deployment.Spec.Template.Spec.TerminationGracePeriodSeconds, -1))
+			}
+		})
+	}
+}
+
+func TestLoadOperandConfigsPodMetadata(t *testing.T) {
+	configs, err := LoadOperandConfigs(writeOperandConfig(t, `catalogd:
+  podAnnotations:
+    prometheus.io/scrape: "true"
+    prometheus.io/port: "7443"
+  podLabels:
+    openshift.io/scrape: "true"
+`), "catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := OperandConfigs{
+		"catalogd": {
+			PodAnnotations: map[string]string{"prometheus.io/scrape": "true", "prometheus.io/port": "7443"},
+			PodLabels:      map[string]string{"openshift.io/scrape": "true"},
+		},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v, got %+v", expected, configs)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "invalid annotation key",
+			content: `catalogd:
+  podAnnotations:
+    "prometheus.io/scrape port": "true"
+`,
+			expectedError: `catalogd: podAnnotations key "prometheus.io/scrape port" is invalid`,
+		},
+		{
+			name: "invalid label key",
+			content: `catalogd:
+  podLabels:
+    "-scrape": "true"
+`,
+			expectedError: `catalogd: podLabels key "-scrape" is invalid`,
+		},
+		{
+			name: "invalid label value",
+			content: `catalogd:
+  podLabels:
+    scrape: "yes please"
+`,
+			expectedError: `catalogd: podLabels "scrape" value "yes please" is invalid`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestPodMetadataHook(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Template.Labels = map[string]string{"control-plane": "catalogd-controller-manager"}
+	deployment.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/default-container": "manager", "prometheus.io/port": "8443"}
+
+	applyDeploymentHooks(t, OperandConfig{
+		PodAnnotations: map[string]string{"prometheus.io/scrape": "true", "prometheus.io/port": "7443"},
+		PodLabels:      map[string]string{"openshift.io/scrape": "true"},
+	}, deployment)
+
+	expectedAnnotations := map[string]string{
+		"kubectl.kubernetes.io/default-container": "manager",
+		"prometheus.io/scrape":                    "true",
+		"prometheus.io/port":                      "7443",
+	}
+	if !reflect.DeepEqual(expectedAnnotations, deployment.Spec.Template.Annotations) {
+		t.Errorf("expected pod annotations %v, got %v", expectedAnnotations, deployment.Spec.Template.Annotations)
+	}
+	expectedLabels := map[string]string{
+		"control-plane":       "catalogd-controller-manager",
+		"openshift.io/scrape": "true",
+	}
+	if !reflect.DeepEqual(expectedLabels, deployment.Spec.Template.Labels) {
+		t.Errorf("expected pod labels %v, got %v", expectedLabels, deployment.Spec.Template.Labels)
+	}
+	if deployment.Annotations != nil || deployment.Labels != nil {
+		t.Errorf("expected the Deployment metadata to be left alone, got annotations %v and labels %v", deployment.Annotations, deployment.Labels)
+	}
+}
+
+func TestLoadOperandConfigsSecurityContexts(t *testing.T) {
+	configs, err := LoadOperandConfigs(writeOperandConfig(t, `operator-controller:
+  securityContexts:
+  - container: manager
+    readOnlyRootFilesystem: true
+    runAsUser: 1000
+    seccompProfile:
+      type: Localhost
+      localhostProfile: profiles/operator-controller.json
+`), "catalogd", "operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := OperandConfigs{
+		"operator-controller": {
+			SecurityContexts: []ContainerSecurityContext{{
+				Container:              "manager",
+				ReadOnlyRootFilesystem: ptr.To(true),
+				RunAsUser:              ptr.To(int64(1000)),
+				SeccompProfile:         &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: ptr.To("profiles/operator-controller.json")},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(expected, configs) {
+		t.Errorf("expected configs %+v, got %+v", expected, configs)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name: "missing container",
+			content: `catalogd:
+  securityContexts:
+  - runAsUser: 1000
+`,
+			expectedError: `catalogd: securityContexts[0].container is required`,
+		},
+		{
+			name: "duplicated container",
+			content: `catalogd:
+  securityContexts:
+  - container: manager
+    runAsUser: 1000
+  - container: manager
+    readOnlyRootFilesystem: true
+`,
+			expectedError: `catalogd: securityContexts[1].container "manager" is duplicated`,
+		},
+		{
+			name: "negative user",
+			content: `catalogd:
+  securityContexts:
+  - container: manager
+    runAsUser: -1
+`,
+			expectedError: `catalogd: securityContexts[0]: runAsUser -1 must be between 0 and 2147483647`,
+		},
+		{
+			name: "unconfined seccomp profile",
+			content: `catalogd:
+  securityContexts:
+  - container: manager
+    seccompProfile:
+      type: Unconfined
+`,
+			expectedError: `catalogd: securityContexts[0]: seccompProfile.type "Unconfined" is not supported`,
+		},
+		{
+			name: "localhost seccomp profile without a profile",
+			content: `catalogd:
+  securityContexts:
+  - container: manager
+    seccompProfile:
+      type: Localhost
+`,
+			expectedError: `catalogd: securityContexts[0]: seccompProfile.localhostProfile is required for type "Localhost"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadOperandConfigs(writeOperandConfig(t, tc.content), "catalogd", "operator-controller")
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestSecurityContextsHook(t *testing.T) {
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "manager", SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: ptr.To(false),
+								ReadOnlyRootFilesystem:   ptr.To(false),
+							}},
+							{Name: "kube-rbac-proxy"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("merges into the named containers", func(t *testing.T) {
+		deployment := newDeployment()
+		runtimeDefault := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+		applyDeploymentHooks(t, OperandConfig{
+			SecurityContexts: []ContainerSecurityContext{
+				{Container: "manager", ReadOnlyRootFilesystem: ptr.To(true), RunAsUser: ptr.To(int64(1000))},
+				{Container: "kube-rbac-proxy", SeccompProfile: runtimeDefault},
+			},
+		}, deployment)
+
+		containers := deployment.Spec.Template.Spec.Containers
+		expectedManager := &corev1.SecurityContext{
+			AllowPrivilegeEscalation: ptr.To(false),
+			ReadOnlyRootFilesystem:   ptr.To(true),
+			RunAsUser:                ptr.To(int64(1000)),
+		}
+		if !reflect.DeepEqual(expectedManager, containers[0].SecurityContext) {
+			t.Errorf("expected security context %+v for container %q, got %+v", expectedManager, "manager", containers[0].SecurityContext)
+		}
+		expectedProxy := &corev1.SecurityContext{SeccompProfile: runtimeDefault}
+		if !reflect.DeepEqual(expectedProxy, containers[1].SecurityContext) {
+			t.Errorf("expected security context %+v for container %q, got %+v", expectedProxy, "kube-rbac-proxy", containers[1].SecurityContext)
+		}
+	})
+
+	t.Run("unknown container", func(t *testing.T) {
+		hook := securityContextsHook([]ContainerSecurityContext{{Container: "missing", RunAsUser: ptr.To(int64(1000))}})
+		err := hook(nil, newDeployment())
+		if err == nil || !strings.Contains(err.Error(), `container "missing" not found`) {
+			t.Errorf("expected an unknown container error, got %v", err)
+		}
+	})
+}
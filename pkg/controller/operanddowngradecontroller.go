@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	semver "github.com/blang/semver/v4"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	appsv1informers "k8s.io/client-go/informers/apps/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	// operatorVersionAnnotation records, on every operand Deployment, the version
+	// of the newest cluster-olm-operator that has applied it. It is how the operand
+	// version is discovered: the operator stamps it through operatorVersionHook and
+	// never lowers it, so a rolled back operator finds a newer version here.
+	operatorVersionAnnotation = "olm.openshift.io/operator-version"
+
+	typeOperatorDowngradeDegraded   = "OperatorDowngradeDegraded"
+	reasonOperatorDowngradeDetected = "OperatorDowngradeDetected"
+)
+
+// NewOperandDowngradeController returns a controller that reports a degraded condition
+// when any of the given operand Deployments was last applied by a newer operator version.
+func NewOperandDowngradeController(name, operatorVersion string, deployments []types.NamespacedName, deploymentInformer appsv1informers.DeploymentInformer, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &operandDowngradeController{
+		name:             name,
+		operatorVersion:  operatorVersion,
+		deployments:      deployments,
+		deploymentLister: deploymentInformer.Lister(),
+		operatorClient:   operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), deploymentInformer.Informer()).ToController(name, eventRecorder)
+}
+
+type operandDowngradeController struct {
+	name             string
+	operatorVersion  string
+	deployments      []types.NamespacedName
+	deploymentLister appsv1listers.DeploymentLister
+	operatorClient   v1helpers.OperatorClient
+}
+
+func (c *operandDowngradeController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	operatorVersion, err := semver.ParseTolerant(c.operatorVersion)
+	if err != nil {
+		return fmt.Errorf("parsing operator version %q: %w", c.operatorVersion, err)
+	}
+
+	var newer []string
+	for _, key := range c.deployments {
+		deployment, err := c.deploymentLister.Deployments(key.Namespace).Get(key.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("fetching Deployment %q: %w", key, err)
+		}
+		recorded, ok := recordedOperatorVersion(deployment)
+		if ok && recorded.GT(operatorVersion) {
+			newer = append(newer, fmt.Sprintf("%s (%s)", key, recorded))
+		}
+	}
+
+	cond := operatorv1.OperatorCondition{
+		Type:   typeOperatorDowngradeDegraded,
+		Status: operatorv1.ConditionFalse,
+		Reason: reasonAsExpected,
+	}
+	if len(newer) > 0 {
+		logger.Info("suspected operator downgrade", "operatorVersion", c.operatorVersion, "deployments", newer)
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = reasonOperatorDowngradeDetected
+		cond.Message = fmt.Sprintf("operator version %s is older than the version that last applied the operand Deployments %s; the operator may not correctly manage the newer operands", c.operatorVersion, strings.Join(newer, ", "))
+	}
+
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
+
+// recordedOperatorVersion returns the operator version stamped on the Deployment,
+// if present and parseable.
+func recordedOperatorVersion(deployment *appsv1.Deployment) (semver.Version, bool) {
+	value, ok := deployment.GetAnnotations()[operatorVersionAnnotation]
+	if !ok {
+		return semver.Version{}, false
+	}
+	v, err := semver.ParseTolerant(value)
+	if err != nil {
+		return semver.Version{}, false
+	}
+	return v, true
+}
+
+// operatorVersionHook stamps the operator version on the Deployment, unless the live
+// Deployment already records a newer one, in which case that version is preserved so
+// the downgrade stays detectable.
+func operatorVersionHook(operatorVersion string, deploymentLister appsv1listers.DeploymentLister) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		stamped := operatorVersion
+		if current, err := semver.ParseTolerant(operatorVersion); err == nil {
+			existing, err := deploymentLister.Deployments(deployment.Namespace).Get(deployment.Name)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("fetching Deployment %s/%s: %w", deployment.Namespace, deployment.Name, err)
+			}
+			if existing != nil {
+				if recorded, ok := recordedOperatorVersion(existing); ok && recorded.GT(current) {
+					stamped = existing.Annotations[operatorVersionAnnotation]
+				}
+			}
+		}
+		if deployment.Annotations == nil {
+			deployment.Annotations = map[string]string{}
+		}
+		deployment.Annotations[operatorVersionAnnotation] = stamped
+		return nil
+	}
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func deploymentWithOperatorVersion(name, version string) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name}}
+	if version != "" {
+		deployment.Annotations = map[string]string{operatorVersionAnnotation: version}
+	}
+	return deployment
+}
+
+func deploymentLister(t *testing.T, deployments ...*appsv1.Deployment) appsv1listers.DeploymentLister {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, d := range deployments {
+		if err := indexer.Add(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return appsv1listers.NewDeploymentLister(indexer)
+}
+
+func TestOperandDowngradeControllerSync(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		deployments     []*appsv1.Deployment
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name: "same version",
+			deployments: []*appsv1.Deployment{
+				deploymentWithOperatorVersion("a", "4.18.0"),
+				deploymentWithOperatorVersion("b", "4.18.0"),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name: "older or unrecorded versions",
+			deployments: []*appsv1.Deployment{
+				deploymentWithOperatorVersion("a", "4.17.3"),
+				deploymentWithOperatorVersion("b", ""),
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name: "downgrade detected",
+			deployments: []*appsv1.Deployment{
+				deploymentWithOperatorVersion("a", "4.18.0"),
+				deploymentWithOperatorVersion("b", "4.19.1"),
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonOperatorDowngradeDetected,
+			expectedMessage: "operator version 4.18.0 is older than the version that last applied the operand Deployments test/b (4.19.1); the operator may not correctly manage the newer operands",
+		},
+		{
+			name:           "deployments not yet created",
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &operandDowngradeController{
+				name:            "test",
+				operatorVersion: "4.18.0",
+				deployments: []types.NamespacedName{
+					{Namespace: "test", Name: "a"},
+					{Namespace: "test", Name: "b"},
+				},
+				deploymentLister: deploymentLister(t, tc.deployments...),
+				operatorClient:   operatorClient,
+			}
+
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeOperatorDowngradeDegraded, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
+
+func TestOperatorVersionHook(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing []*appsv1.Deployment
+		expected string
+	}{
+		{
+			name:     "new deployment is stamped",
+			expected: "4.18.0",
+		},
+		{
+			name:     "older version is replaced",
+			existing: []*appsv1.Deployment{deploymentWithOperatorVersion("a", "4.17.0")},
+			expected: "4.18.0",
+		},
+		{
+			name:     "newer version is preserved",
+			existing: []*appsv1.Deployment{deploymentWithOperatorVersion("a", "4.19.0")},
+			expected: "4.19.0",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hook := operatorVersionHook("4.18.0", deploymentLister(t, tc.existing...))
+			deployment := deploymentWithOperatorVersion("a", "")
+			if err := hook(&operatorv1.OperatorSpec{}, deployment); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := deployment.Annotations[operatorVersionAnnotation]; actual != tc.expected {
+				t.Errorf("expected version %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
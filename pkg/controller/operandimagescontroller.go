@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	imagev1 "github.com/openshift/api/image/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	typeOperandImagesDegraded  = "OperandImagesDegraded"
+	reasonOperandImageMismatch = "OperandImageMismatch"
+)
+
+// releaseImageTags maps the operand image environment variables to their tag in the
+// release image references, the ImageStream listing the images of the payload.
+var releaseImageTags = map[string]string{
+	"CATALOGD_IMAGE":            "olm-catalogd",
+	"OPERATOR_CONTROLLER_IMAGE": "olm-operator-controller",
+	"KUBE_RBAC_PROXY_IMAGE":     "kube-rbac-proxy",
+}
+
+// LoadReleaseImages reads the release image references ImageStream at path and returns
+// the approved operand image references keyed by environment variable.
+func LoadReleaseImages(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading release image references %q: %w", path, err)
+	}
+	var imageStream imagev1.ImageStream
+	if err := yaml.Unmarshal(data, &imageStream); err != nil {
+		return nil, fmt.Errorf("error parsing release image references %q: %w", path, err)
+	}
+
+	tagImages := make(map[string]string, len(imageStream.Spec.Tags))
+	for _, tag := range imageStream.Spec.Tags {
+		if tag.From != nil && tag.From.Kind == "DockerImage" {
+			tagImages[tag.Name] = tag.From.Name
+		}
+	}
+	images := make(map[string]string, len(releaseImageTags))
+	var errs []error
+	for envVar, tag := range releaseImageTags {
+		image, ok := tagImages[tag]
+		if !ok {
+			errs = append(errs, fmt.Errorf("tag %q of %s is missing", tag, envVar))
+			continue
+		}
+		images[envVar] = image
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid release image references %q: %w", path, err)
+	}
+	return images, nil
+}
+
+// NewOperandImagesController returns a controller reporting a degraded condition when
+// the operand images the operator was configured with differ from those approved by
+// the release. Both maps are keyed by the operand image environment variables.
+func NewOperandImagesController(name string, releaseImages, operandImages map[string]string, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &operandImagesController{
+		name:           name,
+		releaseImages:  releaseImages,
+		operandImages:  operandImages,
+		operatorClient: operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer()).ToController(name, eventRecorder)
+}
+
+type operandImagesController struct {
+	name           string
+	releaseImages  map[string]string
+	operandImages  map[string]string
+	operatorClient v1helpers.OperatorClient
+}
+
+func (c *operandImagesController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	var mismatched []string
+	for envVar, expected := range c.releaseImages {
+		if image := c.operandImages[envVar]; image != expected {
+			mismatched = append(mismatched, fmt.Sprintf("%s is %q instead of %q", envVar, image, expected))
+		}
+	}
+	sort.Strings(mismatched)
+
+	cond := operatorv1.OperatorCondition{
+		Type:   typeOperandImagesDegraded,
+		Status: operatorv1.ConditionFalse,
+		Reason: reasonAsExpected,
+	}
+	if len(mismatched) > 0 {
+		logger.Info("operand images differ from the release", "mismatches", mismatched)
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = reasonOperandImageMismatch
+		cond.Message = fmt.Sprintf("the operand images do not match the release image references: %s", strings.Join(mismatched, ", "))
+	}
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
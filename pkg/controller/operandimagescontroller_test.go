@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+const testReleaseImageReferences = `kind: ImageStream
+apiVersion: image.openshift.io/v1
+spec:
+  tags:
+  - name: cluster-olm-operator
+    from:
+      kind: DockerImage
+      name: quay.io/openshift/origin-cluster-olm-operator@sha256:0000
+  - name: olm-catalogd
+    from:
+      kind: DockerImage
+      name: quay.io/openshift/origin-olm-catalogd@sha256:1111
+  - name: olm-operator-controller
+    from:
+      kind: DockerImage
+      name: quay.io/openshift/origin-olm-operator-controller@sha256:2222
+  - name: kube-rbac-proxy
+    from:
+      kind: DockerImage
+      name: quay.io/openshift/origin-kube-rbac-proxy@sha256:3333
+`
+
+func writeReleaseImageReferences(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "image-references")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadReleaseImages(t *testing.T) {
+	images, err := LoadReleaseImages(writeReleaseImageReferences(t, testReleaseImageReferences))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"CATALOGD_IMAGE":            "quay.io/openshift/origin-olm-catalogd@sha256:1111",
+		"OPERATOR_CONTROLLER_IMAGE": "quay.io/openshift/origin-olm-operator-controller@sha256:2222",
+		"KUBE_RBAC_PROXY_IMAGE":     "quay.io/openshift/origin-kube-rbac-proxy@sha256:3333",
+	}
+	if !reflect.DeepEqual(expected, images) {
+		t.Errorf("expected images %v, got %v", expected, images)
+	}
+
+	_, err = LoadReleaseImages(writeReleaseImageReferences(t, `kind: ImageStream
+apiVersion: image.openshift.io/v1
+spec:
+  tags:
+  - name: olm-catalogd
+    from:
+      kind: DockerImage
+      name: quay.io/openshift/origin-olm-catalogd@sha256:1111
+`))
+	if err == nil {
+		t.Error("expected an error for missing operand tags")
+	}
+}
+
+func TestOperandImagesControllerSync(t *testing.T) {
+	releaseImages := map[string]string{
+		"CATALOGD_IMAGE":            "quay.io/openshift/origin-olm-catalogd@sha256:1111",
+		"OPERATOR_CONTROLLER_IMAGE": "quay.io/openshift/origin-olm-operator-controller@sha256:2222",
+	}
+	for _, tc := range []struct {
+		name            string
+		operandImages   map[string]string
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name: "matching images",
+			operandImages: map[string]string{
+				"CATALOGD_IMAGE":            "quay.io/openshift/origin-olm-catalogd@sha256:1111",
+				"OPERATOR_CONTROLLER_IMAGE": "quay.io/openshift/origin-olm-operator-controller@sha256:2222",
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name: "mismatched images",
+			operandImages: map[string]string{
+				"CATALOGD_IMAGE":            "quay.io/openshift/origin-olm-catalogd@sha256:9999",
+				"OPERATOR_CONTROLLER_IMAGE": "quay.io/openshift/origin-olm-operator-controller@sha256:2222",
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonOperandImageMismatch,
+			expectedMessage: `the operand images do not match the release image references: CATALOGD_IMAGE is "quay.io/openshift/origin-olm-catalogd@sha256:9999" instead of "quay.io/openshift/origin-olm-catalogd@sha256:1111"`,
+		},
+		{
+			name: "missing image",
+			operandImages: map[string]string{
+				"CATALOGD_IMAGE": "quay.io/openshift/origin-olm-catalogd@sha256:1111",
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonOperandImageMismatch,
+			expectedMessage: `the operand images do not match the release image references: OPERATOR_CONTROLLER_IMAGE is "" instead of "quay.io/openshift/origin-olm-operator-controller@sha256:2222"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &operandImagesController{
+				name:           "test",
+				releaseImages:  releaseImages,
+				operandImages:  tc.operandImages,
+				operatorClient: operatorClient,
+			}
+
+			if err := c.sync(context.Background(), factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeOperandImagesDegraded, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/management"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	appsv1informers "k8s.io/client-go/informers/apps/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+// operandLeaseCleanupResyncInterval bounds how long a lock renewed by a terminating
+// operand pod after its cleanup survives.
+const operandLeaseCleanupResyncInterval = time.Minute
+
+// OperandLeaderElectionLocks maps the name of each operand Deployment to the name of
+// the leader election lock its manager acquires in the Deployment namespace. The
+// names are set by the operand binaries, not by their manifests.
+var OperandLeaderElectionLocks = map[string]string{
+	"catalogd-controller-manager":            "catalogd-operator-lock",
+	"operator-controller-controller-manager": "9c4404e7.operatorframework.io",
+}
+
+// NewOperandLeaseCleanupController returns a controller that, once the OLM resource is
+// Removed and an operand Deployment is deleted, deletes the leader election lock of the
+// Deployment so that re-enabling the operand does not wait for a stale lock to expire.
+// Both the Lease and the ConfigMap lock an older operand may have used are deleted.
+func NewOperandLeaseCleanupController(name string, deployments []types.NamespacedName, locks map[string]string, deploymentInformer appsv1informers.DeploymentInformer, leaseClient coordinationv1client.LeasesGetter, configMapClient corev1client.ConfigMapsGetter, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &operandLeaseCleanupController{
+		name:             name,
+		deployments:      deployments,
+		locks:            locks,
+		deploymentLister: deploymentInformer.Lister(),
+		leaseClient:      leaseClient,
+		configMapClient:  configMapClient,
+		operatorClient:   operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), deploymentInformer.Informer()).ResyncEvery(operandLeaseCleanupResyncInterval).ToController(name, eventRecorder)
+}
+
+type operandLeaseCleanupController struct {
+	name             string
+	deployments      []types.NamespacedName
+	locks            map[string]string
+	deploymentLister appsv1listers.DeploymentLister
+	leaseClient      coordinationv1client.LeasesGetter
+	configMapClient  corev1client.ConfigMapsGetter
+	operatorClient   v1helpers.OperatorClient
+}
+
+func (c *operandLeaseCleanupController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	operatorSpec, _, _, err := c.operatorClient.GetOperatorState()
+	if err != nil {
+		return err
+	}
+	// the deployment controllers only delete the operands of a removable operator
+	if operatorSpec.ManagementState != operatorv1.Removed || !management.IsOperatorRemovable() {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range c.deployments {
+		lock, ok := c.locks[key.Name]
+		if !ok {
+			continue
+		}
+		_, err := c.deploymentLister.Deployments(key.Namespace).Get(key.Name)
+		if err == nil {
+			// still running operand pods would acquire the lock again
+			logger.V(4).Info("waiting for the operand Deployment to be deleted", "deployment", key)
+			continue
+		}
+		if !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("fetching Deployment %q: %w", key, err))
+			continue
+		}
+		if err := c.deleteLock(ctx, syncCtx.Recorder(), key.Namespace, lock); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deleteLock deletes the Lease and ConfigMap leader election locks of the given name.
+func (c *operandLeaseCleanupController) deleteLock(ctx context.Context, recorder events.Recorder, namespace, name string) error {
+	err := c.leaseClient.Leases(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	switch {
+	case err == nil:
+		recorder.Eventf("OperandLeaseDeleted", "Deleted the leader election Lease %s/%s of the removed operand", namespace, name)
+	case !apierrors.IsNotFound(err):
+		return fmt.Errorf("deleting Lease %s/%s: %w", namespace, name, err)
+	}
+
+	err = c.configMapClient.ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	switch {
+	case err == nil:
+		recorder.Eventf("OperandLeaseDeleted", "Deleted the leader election ConfigMap %s/%s of the removed operand", namespace, name)
+	case !apierrors.IsNotFound(err):
+		return fmt.Errorf("deleting ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
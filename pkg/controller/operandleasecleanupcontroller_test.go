@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOperandLeaseCleanupControllerSync(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		managementState operatorv1.ManagementState
+		deployments     []*appsv1.Deployment
+		expectDeleted   bool
+	}{
+		{
+			name:            "managed operands keep their lock",
+			managementState: operatorv1.Managed,
+		},
+		{
+			name:            "removed operands not yet deleted keep their lock",
+			managementState: operatorv1.Removed,
+			deployments:     []*appsv1.Deployment{{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "manager"}}},
+		},
+		{
+			name:            "deleted operands release their lock",
+			managementState: operatorv1.Removed,
+			expectDeleted:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			kubeClient := kubefake.NewSimpleClientset(
+				&coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "manager-lock"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "manager-lock"}},
+			)
+			c := &operandLeaseCleanupController{
+				name: "test",
+				deployments: []types.NamespacedName{
+					{Namespace: "test", Name: "manager"},
+					// Deployments with no known lock are ignored
+					{Namespace: "test", Name: "other"},
+				},
+				locks:            map[string]string{"manager": "manager-lock"},
+				deploymentLister: deploymentLister(t, tc.deployments...),
+				leaseClient:      kubeClient.CoordinationV1(),
+				configMapClient:  kubeClient.CoreV1(),
+				operatorClient:   v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: tc.managementState}, &operatorv1.OperatorStatus{}, nil),
+			}
+
+			syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+			if err := c.sync(ctx, syncCtx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// locks already deleted are not an error
+			if err := c.sync(ctx, syncCtx); err != nil {
+				t.Fatalf("unexpected error on second sync: %v", err)
+			}
+
+			_, leaseErr := kubeClient.CoordinationV1().Leases("test").Get(ctx, "manager-lock", metav1.GetOptions{})
+			_, configMapErr := kubeClient.CoreV1().ConfigMaps("test").Get(ctx, "manager-lock", metav1.GetOptions{})
+			for kind, err := range map[string]error{"Lease": leaseErr, "ConfigMap": configMapErr} {
+				if tc.expectDeleted && !apierrors.IsNotFound(err) {
+					t.Errorf("expected the %s lock to be deleted, got %v", kind, err)
+				}
+				if !tc.expectDeleted && err != nil {
+					t.Errorf("expected the %s lock to be kept, got %v", kind, err)
+				}
+			}
+		})
+	}
+}
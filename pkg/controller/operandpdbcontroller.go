@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	appsv1informers "k8s.io/client-go/informers/apps/v1"
+	policyv1client "k8s.io/client-go/kubernetes/typed/policy/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+// NewOperandPDBController returns a controller ensuring that every operand Deployment
+// running more than one replica has a PodDisruptionBudget letting at most one of its
+// pods be evicted at a time. The PodDisruptionBudgets are named after and owned by
+// their Deployment, and deleted once it scales down to a single replica; those of the
+// same name not controlled by the Deployment are left alone. Deployments
+// in the renderedPDBNamespaces, whose manifests include a PodDisruptionBudget, are
+// left to that one.
+func NewOperandPDBController(name string, deployments []types.NamespacedName, renderedPDBNamespaces sets.Set[string], deploymentInformer appsv1informers.DeploymentInformer, pdbClient policyv1client.PodDisruptionBudgetsGetter, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &operandPDBController{
+		name:                  name,
+		deployments:           deployments,
+		renderedPDBNamespaces: renderedPDBNamespaces,
+		deploymentLister:      deploymentInformer.Lister(),
+		pdbClient:             pdbClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer(), deploymentInformer.Informer()).ToController(name, eventRecorder)
+}
+
+type operandPDBController struct {
+	name                  string
+	deployments           []types.NamespacedName
+	renderedPDBNamespaces sets.Set[string]
+	deploymentLister      appsv1listers.DeploymentLister
+	pdbClient             policyv1client.PodDisruptionBudgetsGetter
+}
+
+func (c *operandPDBController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	var errs []error
+	for _, key := range c.deployments {
+		if c.renderedPDBNamespaces.Has(key.Namespace) {
+			continue
+		}
+		deployment, err := c.deploymentLister.Deployments(key.Namespace).Get(key.Name)
+		if apierrors.IsNotFound(err) {
+			// the PodDisruptionBudget is garbage collected along with its Deployment
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("fetching Deployment %q: %w", key, err))
+			continue
+		}
+
+		required := operandPDB(deployment)
+		existing, err := c.pdbClient.PodDisruptionBudgets(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			existing = nil
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("fetching PodDisruptionBudget %s/%s: %w", required.Namespace, required.Name, err))
+			continue
+		}
+		// PodDisruptionBudgets named after the Deployment but created by someone else are left alone
+		if existing != nil && !metav1.IsControlledBy(existing, deployment) {
+			logger.Info("PodDisruptionBudget not controlled by its Deployment is left unchanged", "namespace", required.Namespace, "name", required.Name)
+			continue
+		}
+
+		if ptr.Deref(deployment.Spec.Replicas, 1) > 1 {
+			if _, _, err := resourceapply.ApplyPodDisruptionBudget(ctx, c.pdbClient, syncCtx.Recorder(), required); err != nil {
+				errs = append(errs, fmt.Errorf("applying PodDisruptionBudget %s/%s: %w", required.Namespace, required.Name, err))
+			}
+			continue
+		}
+		if existing == nil {
+			continue
+		}
+		if _, _, err := resourceapply.DeletePodDisruptionBudget(ctx, c.pdbClient, syncCtx.Recorder(), required); err != nil {
+			errs = append(errs, fmt.Errorf("deleting PodDisruptionBudget %s/%s: %w", required.Namespace, required.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// operandPDB returns the PodDisruptionBudget of the Deployment.
+func operandPDB(deployment *appsv1.Deployment) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       deployment.Namespace,
+			Name:            deployment.Name,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+			Selector:       deployment.Spec.Selector.DeepCopy(),
+		},
+	}
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestOperandPDBControllerSync(t *testing.T) {
+	deploymentWithReplicas := func(replicas *int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "manager", UID: "uid"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "manager"}},
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name          string
+		deployment    *appsv1.Deployment
+		existing      []*policyv1.PodDisruptionBudget
+		renderedPDB   bool
+		expectPresent bool
+		// expectUnchanged is set when the existing PodDisruptionBudget must be left as is.
+		expectUnchanged bool
+	}{
+		{
+			name:          "multiple replicas",
+			deployment:    deploymentWithReplicas(ptr.To[int32](2)),
+			expectPresent: true,
+		},
+		{
+			name:       "single replica",
+			deployment: deploymentWithReplicas(ptr.To[int32](1)),
+		},
+		{
+			name:       "unset replicas default to one",
+			deployment: deploymentWithReplicas(nil),
+		},
+		{
+			name:       "scaled down to a single replica",
+			deployment: deploymentWithReplicas(ptr.To[int32](1)),
+			existing:   []*policyv1.PodDisruptionBudget{operandPDB(deploymentWithReplicas(ptr.To[int32](2)))},
+		},
+		{
+			name:       "PodDisruptionBudgets not owned by the Deployment are kept",
+			deployment: deploymentWithReplicas(ptr.To[int32](1)),
+			existing: []*policyv1.PodDisruptionBudget{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "manager"},
+			}},
+			expectPresent: true,
+		},
+		{
+			name:       "PodDisruptionBudgets not owned by a multi-replica Deployment are left unchanged",
+			deployment: deploymentWithReplicas(ptr.To[int32](3)),
+			existing: []*policyv1.PodDisruptionBudget{{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "manager"},
+				Spec:       policyv1.PodDisruptionBudgetSpec{MinAvailable: ptr.To(intstr.FromInt32(2))},
+			}},
+			expectPresent:   true,
+			expectUnchanged: true,
+		},
+		{
+			name:        "rendered PodDisruptionBudget",
+			deployment:  deploymentWithReplicas(ptr.To[int32](2)),
+			renderedPDB: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			kubeClient := kubefake.NewSimpleClientset()
+			for _, pdb := range tc.existing {
+				if _, err := kubeClient.PolicyV1().PodDisruptionBudgets(pdb.Namespace).Create(ctx, pdb, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			renderedPDBNamespaces := sets.New[string]()
+			if tc.renderedPDB {
+				renderedPDBNamespaces.Insert("test")
+			}
+			c := &operandPDBController{
+				name:                  "test",
+				deployments:           []types.NamespacedName{{Namespace: "test", Name: "manager"}},
+				renderedPDBNamespaces: renderedPDBNamespaces,
+				deploymentLister:      deploymentLister(t, tc.deployment),
+				pdbClient:             kubeClient.PolicyV1(),
+			}
+
+			if err := c.sync(ctx, factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			pdb, err := kubeClient.PolicyV1().PodDisruptionBudgets("test").Get(ctx, "manager", metav1.GetOptions{})
+			if !tc.expectPresent {
+				if !apierrors.IsNotFound(err) {
+					t.Fatalf("expected no PodDisruptionBudget, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected a PodDisruptionBudget, got %v", err)
+			}
+			if tc.expectUnchanged {
+				if !equality.Semantic.DeepEqual(tc.existing[0].Spec, pdb.Spec) || len(pdb.OwnerReferences) > 0 {
+					t.Errorf("expected the PodDisruptionBudget to be left unchanged, got %v", pdb)
+				}
+				return
+			}
+			if len(tc.existing) > 0 {
+				return
+			}
+			if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 {
+				t.Errorf("expected maxUnavailable 1, got %v", pdb.Spec.MaxUnavailable)
+			}
+			if pdb.Spec.Selector == nil || pdb.Spec.Selector.MatchLabels["app"] != "manager" {
+				t.Errorf("expected the Deployment selector, got %v", pdb.Spec.Selector)
+			}
+			if !metav1.IsControlledBy(pdb, tc.deployment) {
+				t.Errorf("expected the PodDisruptionBudget to be owned by the Deployment, got %v", pdb.OwnerReferences)
+			}
+		})
+	}
+}
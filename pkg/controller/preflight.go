@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	semver "github.com/blang/semver/v4"
+	"github.com/go-logr/logr"
+	ocv1 "github.com/operator-framework/operator-controller/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/openshift/cluster-olm-operator/pkg/compatibility"
+)
+
+// preflightResponse is the result of evaluating the installed operators against a target version.
+type preflightResponse struct {
+	TargetVersion         string                 `json:"targetVersion"`
+	Upgradeable           bool                   `json:"upgradeable"`
+	IncompatibleOperators []incompatibleOperator `json:"incompatibleOperators"`
+	// Error describes the extensions that could not be evaluated, if any; the
+	// operators listed were incompatible regardless.
+	Error string `json:"error,omitempty"`
+}
+
+// scanResponse is the outcome of evaluating every installed operator against a target version.
+type scanResponse struct {
+	TargetVersion string       `json:"targetVersion"`
+	Extensions    []scanResult `json:"extensions"`
+	// Error describes the extensions that could not be evaluated, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// NewIncompatibleOperatorsPreflightHandler returns a read-only handler answering whether
+// upgrading to the Major.Minor given in the version query parameter would be blocked by
+// the installed operators. It runs the checks of the incompatible operator controller,
+// reading ClusterExtensions and Helm releases from the API server rather than from caches,
+// with the request context.
+func NewIncompatibleOperatorsPreflightHandler(dynamicClient dynamic.Interface, kubeclient kubernetes.Interface, helmReleaseNamespace string, logger logr.Logger) http.Handler {
+	c := newAPIServerIncompatibleOperatorController(dynamicClient, kubeclient, helmReleaseNamespace, logger)
+	return &preflightHandler{incompatibleOperatorsFunc: c.getIncompatibleOperators, logger: logger}
+}
+
+// NewIncompatibleOperatorsScanHandler returns a read-only handler dumping, for the
+// Major.Minor given in the version query parameter, the outcome of the preflight checks
+// for every installed ClusterExtension, whether or not it blocks the upgrade, evaluated
+// with the request context.
+func NewIncompatibleOperatorsScanHandler(dynamicClient dynamic.Interface, kubeclient kubernetes.Interface, helmReleaseNamespace string, logger logr.Logger) http.Handler {
+	c := newAPIServerIncompatibleOperatorController(dynamicClient, kubeclient, helmReleaseNamespace, logger)
+	return &scanHandler{scanFunc: c.scanClusterExtensions, logger: logger}
+}
+
+// newAPIServerIncompatibleOperatorController returns an incompatible operator controller
+// that only evaluates extensions, reading them from the API server.
+func newAPIServerIncompatibleOperatorController(dynamicClient dynamic.Interface, kubeclient kubernetes.Interface, helmReleaseNamespace string, logger logr.Logger) *incompatibleOperatorController {
+	c := &incompatibleOperatorController{
+		kubeclient:           kubeclient,
+		checks:               []compatibility.Check{compatibility.MaxOpenShiftVersionCheck},
+		logger:               logger,
+		helmReleaseNamespace: helmReleaseNamespace,
+		helmStorageOwner:     helmStorageOwner,
+	}
+	c.listClusterExtensions = func(ctx context.Context) ([]runtime.Object, error) {
+		// an unset resourceVersion is served from etcd with a quorum read
+		list, err := dynamicClient.Resource(ocv1.GroupVersion.WithResource("clusterextensions")).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		objs := make([]runtime.Object, 0, len(list.Items))
+		for i := range list.Items {
+			objs = append(objs, &list.Items[i])
+		}
+		return objs, nil
+	}
+	return c
+}
+
+type preflightHandler struct {
+	incompatibleOperatorsFunc func(ctx context.Context, targetVersion semver.Version) ([]incompatibleOperator, error)
+	logger                    logr.Logger
+}
+
+func (h *preflightHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targetVersion, ok := targetVersionFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	operators, err := h.incompatibleOperatorsFunc(r.Context(), *targetVersion)
+	response := preflightResponse{
+		TargetVersion:         fmt.Sprintf("%d.%d", targetVersion.Major, targetVersion.Minor),
+		Upgradeable:           len(operators) == 0 && err == nil,
+		IncompatibleOperators: operators,
+	}
+	if response.IncompatibleOperators == nil {
+		response.IncompatibleOperators = []incompatibleOperator{}
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error(err, "failed to write the preflight response")
+	}
+}
+
+type scanHandler struct {
+	scanFunc func(ctx context.Context, targetVersion semver.Version) ([]scanResult, error)
+	logger   logr.Logger
+}
+
+func (h *scanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	targetVersion, ok := targetVersionFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := h.scanFunc(r.Context(), *targetVersion)
+	response := scanResponse{
+		TargetVersion: fmt.Sprintf("%d.%d", targetVersion.Major, targetVersion.Minor),
+		Extensions:    results,
+	}
+	if response.Extensions == nil {
+		response.Extensions = []scanResult{}
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		h.logger.Error(err, "failed to write the scan response")
+	}
+}
+
+// targetVersionFromRequest returns the Major.Minor version of the version query
+// parameter of a GET request, or writes the error response and returns false.
+func targetVersionFromRequest(w http.ResponseWriter, r *http.Request) (*semver.Version, bool) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	requested := r.URL.Query().Get("version")
+	if requested == "" {
+		http.Error(w, "the version query parameter is required", http.StatusBadRequest)
+		return nil, false
+	}
+	data, _ := json.Marshal(requested)
+	targetVersion, err := compatibility.NormalizeVersion(data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid version %q: %v", requested, err), http.StatusBadRequest)
+		return nil, false
+	}
+	return targetVersion, true
+}
@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	semver "github.com/blang/semver/v4"
+	"github.com/go-logr/logr"
+	storage "github.com/operator-framework/helm-operator-plugins/pkg/storage"
+	ocv1 "github.com/operator-framework/operator-controller/api/v1"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	helmstorage "helm.sh/helm/v3/pkg/storage"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIncompatibleOperatorsPreflightHandler(t *testing.T) {
+	clusterExtension := &unstructured.Unstructured{}
+	clusterExtension.SetGroupVersionKind(ocv1.GroupVersion.WithKind("ClusterExtension"))
+	clusterExtension.SetName("foo")
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		ocv1.GroupVersion.WithResource("clusterextensions"): "ClusterExtensionList",
+	}, clusterExtension)
+
+	kubeClient := kubefake.NewSimpleClientset()
+	writer := helmstorage.Init(storage.NewChunkedSecrets(kubeClient.CoreV1().Secrets("openshift-operator-controller"), helmStorageOwner, storage.ChunkedSecretsConfig{
+		ChunkSize: 1024 * 1024,
+		Log:       func(string, ...interface{}) {},
+	}))
+	if err := writer.Create(&release.Release{
+		Name:    "foo",
+		Version: 1,
+		Info:    &release.Info{Status: release.StatusDeployed},
+		Labels:  map[string]string{bundleNameKey: "foo.v1.0.0", packageNameKey: "foo"},
+		Chart: &chart.Chart{Metadata: &chart.Metadata{Annotations: map[string]string{
+			"olm.properties": `[{"type":"olm.maxOpenShiftVersion","value":"4.18"}]`,
+		}}},
+	}); err != nil {
+		t.Fatalf("unexpected error creating release: %v", err)
+	}
+
+	handler := NewIncompatibleOperatorsPreflightHandler(dynamicClient, kubeClient, "openshift-operator-controller", logr.Discard())
+
+	for _, tc := range []struct {
+		name     string
+		version  string
+		expected preflightResponse
+	}{
+		{
+			name:    "target blocked",
+			version: "4.19",
+			expected: preflightResponse{
+				TargetVersion: "4.19",
+				IncompatibleOperators: []incompatibleOperator{
+					{Name: "foo", Bundle: "foo.v1.0.0", Package: "foo", MaxVersion: "4.18"},
+				},
+			},
+		},
+		{
+			name:    "target not blocked",
+			version: "4.18",
+			expected: preflightResponse{
+				TargetVersion:         "4.18",
+				Upgradeable:           true,
+				IncompatibleOperators: []incompatibleOperator{},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/preflight?version="+tc.version, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+			}
+			var actual preflightResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &actual); err != nil {
+				t.Fatalf("unexpected error decoding the response: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected response %+v, got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPreflightHandlerRequests(t *testing.T) {
+	handler := &preflightHandler{
+		incompatibleOperatorsFunc: func(context.Context, semver.Version) ([]incompatibleOperator, error) {
+			return nil, errors.New("error returning the last deployed release for bar")
+		},
+		logger: logr.Discard(),
+	}
+	for _, tc := range []struct {
+		name         string
+		method       string
+		target       string
+		expectedCode int
+	}{
+		{name: "missing version", method: http.MethodGet, target: "/preflight", expectedCode: http.StatusBadRequest},
+		{name: "patch version", method: http.MethodGet, target: "/preflight?version=4.19.1", expectedCode: http.StatusBadRequest},
+		{name: "not a GET", method: http.MethodPost, target: "/preflight?version=4.19", expectedCode: http.StatusMethodNotAllowed},
+		{name: "evaluation errors", method: http.MethodGet, target: "/preflight?version=4.19", expectedCode: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.target, nil))
+			if recorder.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var actual preflightResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &actual); err != nil {
+				t.Fatalf("unexpected error decoding the response: %v", err)
+			}
+			if actual.Upgradeable || actual.Error == "" {
+				t.Errorf("expected a non-upgradeable response reporting the error, got %+v", actual)
+			}
+		})
+	}
+}
+
+func TestHandlersUseRequestContext(t *testing.T) {
+	type contextKey struct{}
+	var evaluatedWith context.Context
+	for _, tc := range []struct {
+		name    string
+		handler http.Handler
+		target  string
+	}{
+		{
+			name: "preflight",
+			handler: &preflightHandler{
+				incompatibleOperatorsFunc: func(ctx context.Context, _ semver.Version) ([]incompatibleOperator, error) {
+					evaluatedWith = ctx
+					return nil, nil
+				},
+				logger: logr.Discard(),
+			},
+			target: "/preflight?version=4.19",
+		},
+		{
+			name: "scan",
+			handler: &scanHandler{
+				scanFunc: func(ctx context.Context, _ semver.Version) ([]scanResult, error) {
+					evaluatedWith = ctx
+					return nil, nil
+				},
+				logger: logr.Discard(),
+			},
+			target: "/scan?version=4.19",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			evaluatedWith = nil
+			request := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			request = request.WithContext(context.WithValue(request.Context(), contextKey{}, "request"))
+
+			tc.handler.ServeHTTP(httptest.NewRecorder(), request)
+			if evaluatedWith == nil || evaluatedWith.Value(contextKey{}) != "request" {
+				t.Errorf("expected the operators to be evaluated with the request context")
+			}
+		})
+	}
+}
+
+func TestIncompatibleOperatorsScanHandler(t *testing.T) {
+	var clusterExtensions []runtime.Object
+	for _, name := range []string{"foo", "bar", "baz"} {
+		clusterExtension := &unstructured.Unstructured{}
+		clusterExtension.SetGroupVersionKind(ocv1.GroupVersion.WithKind("ClusterExtension"))
+		clusterExtension.SetName(name)
+		clusterExtensions = append(clusterExtensions, clusterExtension)
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		ocv1.GroupVersion.WithResource("clusterextensions"): "ClusterExtensionList",
+	}, clusterExtensions...)
+
+	kubeClient := kubefake.NewSimpleClientset()
+	writer := helmstorage.Init(storage.NewChunkedSecrets(kubeClient.CoreV1().Secrets("openshift-operator-controller"), helmStorageOwner, storage.ChunkedSecretsConfig{
+		ChunkSize: 1024 * 1024,
+		Log:       func(string, ...interface{}) {},
+	}))
+	// baz has no deployed release
+	for name, maxVersion := range map[string]string{"foo": "4.18", "bar": "4.20"} {
+		if err := writer.Create(&release.Release{
+			Name:    name,
+			Version: 1,
+			Info:    &release.Info{Status: release.StatusDeployed},
+			Labels:  map[string]string{bundleNameKey: name + ".v1.0.0", packageNameKey: name},
+			Chart: &chart.Chart{Metadata: &chart.Metadata{Annotations: map[string]string{
+				"olm.properties": `[{"type":"olm.maxOpenShiftVersion","value":"` + maxVersion + `"}]`,
+			}}},
+		}); err != nil {
+			t.Fatalf("unexpected error creating release: %v", err)
+		}
+	}
+
+	handler := NewIncompatibleOperatorsScanHandler(dynamicClient, kubeClient, "openshift-operator-controller", logr.Discard())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/scan?version=4.19", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	var actual scanResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &actual); err != nil {
+		t.Fatalf("unexpected error decoding the response: %v", err)
+	}
+	expected := scanResponse{
+		TargetVersion: "4.19",
+		Extensions: []scanResult{
+			{Name: "bar", Bundle: "bar.v1.0.0", Package: "bar", MaxVersion: "4.20", Decision: decisionCompatible},
+			{Name: "baz", Decision: decisionNotDeployed},
+			{Name: "foo", Bundle: "foo.v1.0.0", Package: "foo", MaxVersion: "4.18", Decision: decisionIncompatible, Reasons: []string{"olm.maxOpenShiftVersion=4.18"}},
+		},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected response %+v, got %+v", expected, actual)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/scan", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without a version, got %d", http.StatusBadRequest, recorder.Code)
+	}
+}
+
+func TestPreflightHandlerDiscoveredHelmReleaseNamespace(t *testing.T) {
+	b := Builder{Assets: fstest.MapFS{
+		"operator-controller/deployment.yaml": &fstest.MapFile{Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  namespace: alternate-operator-controller
+  name: operator-controller-controller-manager
+`)},
+	}}
+	helmReleaseNamespace, err := b.DeploymentNamespace("operator-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clusterExtension := &unstructured.Unstructured{}
+	clusterExtension.SetGroupVersionKind(ocv1.GroupVersion.WithKind("ClusterExtension"))
+	clusterExtension.SetName("foo")
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		ocv1.GroupVersion.WithResource("clusterextensions"): "ClusterExtensionList",
+	}, clusterExtension)
+
+	kubeClient := kubefake.NewSimpleClientset()
+	// the release is only found in the namespace of the rendered Deployment
+	writer := helmstorage.Init(storage.NewChunkedSecrets(kubeClient.CoreV1().Secrets("alternate-operator-controller"), helmStorageOwner, storage.ChunkedSecretsConfig{
+		ChunkSize: 1024 * 1024,
+		Log:       func(string, ...interface{}) {},
+	}))
+	if err := writer.Create(&release.Release{
+		Name:    "foo",
+		Version: 1,
+		Info:    &release.Info{Status: release.StatusDeployed},
+		Labels:  map[string]string{bundleNameKey: "foo.v1.0.0", packageNameKey: "foo"},
+		Chart: &chart.Chart{Metadata: &chart.Metadata{Annotations: map[string]string{
+			"olm.properties": `[{"type":"olm.maxOpenShiftVersion","value":"4.18"}]`,
+		}}},
+	}); err != nil {
+		t.Fatalf("unexpected error creating release: %v", err)
+	}
+
+	handler := NewIncompatibleOperatorsPreflightHandler(dynamicClient, kubeClient, helmReleaseNamespace, logr.Discard())
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/preflight?version=4.19", nil))
+	var actual preflightResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &actual); err != nil {
+		t.Fatalf("unexpected error decoding the response %q: %v", recorder.Body.String(), err)
+	}
+	expected := []incompatibleOperator{{Name: "foo", Bundle: "foo.v1.0.0", Package: "foo", MaxVersion: "4.18"}}
+	if !reflect.DeepEqual(expected, actual.IncompatibleOperators) {
+		t.Errorf("expected incompatible operators %+v, got %+v", expected, actual.IncompatibleOperators)
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+// operandHashAnnotation records on the ClusterOperator the hash of the operand
+// manifests and configuration applied by the operator version it also records.
+const operandHashAnnotation = "olm.openshift.io/operand-manifests-hash"
+
+// NewClusterOperatorProvenanceController returns a controller that annotates the named
+// ClusterOperator with the operator version and a hash of the operand manifests and
+// configuration, tying the ClusterOperator to the operands it applies.
+func NewClusterOperatorProvenanceController(name, clusterOperatorName, operatorVersion, operandHash string, clusterOperatorClient configv1client.ClusterOperatorsGetter, clusterOperatorInformer configv1informers.ClusterOperatorInformer, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &clusterOperatorProvenanceController{
+		name:                  name,
+		clusterOperatorName:   clusterOperatorName,
+		operatorVersion:       operatorVersion,
+		operandHash:           operandHash,
+		clusterOperatorClient: clusterOperatorClient,
+		clusterOperatorLister: clusterOperatorInformer.Lister(),
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(clusterOperatorInformer.Informer()).ToController(name, eventRecorder)
+}
+
+type clusterOperatorProvenanceController struct {
+	name                  string
+	clusterOperatorName   string
+	operatorVersion       string
+	operandHash           string
+	clusterOperatorClient configv1client.ClusterOperatorsGetter
+	clusterOperatorLister configv1listers.ClusterOperatorLister
+}
+
+func (c *clusterOperatorProvenanceController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	co, err := c.clusterOperatorLister.Get(c.clusterOperatorName)
+	if apierrors.IsNotFound(err) {
+		// the status controller creates the ClusterOperator
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting clusteroperators.config.openshift.io/%s: %w", c.clusterOperatorName, err)
+	}
+
+	desired := map[string]string{
+		operatorVersionAnnotation: c.operatorVersion,
+		operandHashAnnotation:     c.operandHash,
+	}
+	upToDate := true
+	for key, value := range desired {
+		if co.Annotations[key] != value {
+			upToDate = false
+		}
+	}
+	if upToDate {
+		return nil
+	}
+
+	updated := co.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	for key, value := range desired {
+		updated.Annotations[key] = value
+	}
+	logger.V(2).Info("updating provenance annotations", "operatorVersion", c.operatorVersion, "operandHash", c.operandHash)
+	if _, err := c.clusterOperatorClient.ClusterOperators().Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating clusteroperators.config.openshift.io/%s: %w", c.clusterOperatorName, err)
+	}
+	return nil
+}
+
+// OperandHash returns a short hash of the operand manifests found in the given
+// subdirectories of the assets, of the images substituted in them and of the operand
+// configuration applied to them. Excluded manifests are not rendered, so they are
+// ignored.
+func (b *Builder) OperandHash(subDirectories ...string) (string, error) {
+	h := sha256.New()
+	for _, subDirectory := range subDirectories {
+		if err := fs.WalkDir(b.Assets, subDirectory, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") || b.excludedManifest(path) {
+				return nil
+			}
+			data, err := fs.ReadFile(b.Assets, path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+			_, err = h.Write(data)
+			return err
+		}); err != nil {
+			return "", fmt.Errorf("error hashing operand manifests: %w", err)
+		}
+	}
+	for _, image := range operandImages {
+		fmt.Fprintf(h, "%s\x00%s\x00", image.envVar, os.Getenv(image.envVar))
+	}
+	// json.Marshal sorts map keys, so the encoding is stable
+	config, err := json.Marshal(b.OperandConfigs)
+	if err != nil {
+		return "", fmt.Errorf("error hashing operand config: %w", err)
+	}
+	h.Write(config)
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configfake "github.com/openshift/client-go/config/clientset/versioned/fake"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestClusterOperatorProvenanceControllerSync(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		existingAnnotations map[string]string
+		expectUpdate        bool
+	}{
+		{
+			name:                "annotations set",
+			existingAnnotations: map[string]string{"user": "annotation"},
+			expectUpdate:        true,
+		},
+		{
+			name: "annotations updated on change",
+			existingAnnotations: map[string]string{
+				"user":                    "annotation",
+				operatorVersionAnnotation: "4.17.0",
+				operandHashAnnotation:     "0123456789ab",
+			},
+			expectUpdate: true,
+		},
+		{
+			name: "annotations up to date",
+			existingAnnotations: map[string]string{
+				"user":                    "annotation",
+				operatorVersionAnnotation: "4.18.0",
+				operandHashAnnotation:     "ba9876543210",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			co := &configv1.ClusterOperator{ObjectMeta: metav1.ObjectMeta{Name: "olm", Annotations: tc.existingAnnotations}}
+			client := configfake.NewSimpleClientset(co)
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(co); err != nil {
+				t.Fatal(err)
+			}
+			c := &clusterOperatorProvenanceController{
+				name:                  "test",
+				clusterOperatorName:   "olm",
+				operatorVersion:       "4.18.0",
+				operandHash:           "ba9876543210",
+				clusterOperatorClient: client.ConfigV1(),
+				clusterOperatorLister: configv1listers.NewClusterOperatorLister(indexer),
+			}
+
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			updated := false
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "update" {
+					updated = true
+				}
+			}
+			if updated != tc.expectUpdate {
+				t.Fatalf("expected update: %v, got actions: %v", tc.expectUpdate, client.Actions())
+			}
+
+			actual, err := client.ConfigV1().ClusterOperators().Get(context.Background(), "olm", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := map[string]string{
+				"user":                    "annotation",
+				operatorVersionAnnotation: "4.18.0",
+				operandHashAnnotation:     "ba9876543210",
+			}
+			if !reflect.DeepEqual(expected, actual.Annotations) {
+				t.Errorf("expected annotations %v, got %v", expected, actual.Annotations)
+			}
+		})
+	}
+}
+
+func TestOperandHash(t *testing.T) {
+	hash := func(t *testing.T, b *Builder) string {
+		t.Helper()
+		h, err := b.OperandHash("catalogd")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return h
+	}
+	assets := fstest.MapFS{"catalogd/configmap.yaml": &fstest.MapFile{Data: []byte("kind: ConfigMap\n")}}
+	original := hash(t, &Builder{Assets: assets})
+
+	if len(original) != 12 {
+		t.Errorf("expected a 12 character hash, got %q", original)
+	}
+	if again := hash(t, &Builder{Assets: assets}); again != original {
+		t.Errorf("expected a stable hash, got %q and %q", original, again)
+	}
+
+	changedAssets := fstest.MapFS{"catalogd/configmap.yaml": &fstest.MapFile{Data: []byte("kind: Secret\n")}}
+	if changed := hash(t, &Builder{Assets: changedAssets}); changed == original {
+		t.Error("expected the hash to change with the manifests")
+	}
+	withConfig := &Builder{Assets: assets, OperandConfigs: OperandConfigs{"catalogd": {PriorityClassName: "system-cluster-critical"}}}
+	if changed := hash(t, withConfig); changed == original {
+		t.Error("expected the hash to change with the operand config")
+	}
+
+	withExcluded := fstest.MapFS{
+		"catalogd/configmap.yaml": assets["catalogd/configmap.yaml"],
+		"catalogd/excluded.yaml":  &fstest.MapFile{Data: []byte("kind: Secret\n")},
+	}
+	if excluded := hash(t, &Builder{Assets: withExcluded, ExcludedManifests: []string{"catalogd/excluded.yaml"}}); excluded != original {
+		t.Errorf("expected excluded manifests not to change the hash, got %q and %q", original, excluded)
+	}
+
+	t.Setenv("CATALOGD_IMAGE", "quay.io/example/catalogd@sha256:9999")
+	if changed := hash(t, &Builder{Assets: assets}); changed == original {
+		t.Error("expected the hash to change with the operand images")
+	}
+}
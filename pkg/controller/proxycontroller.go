@@ -3,11 +3,17 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog/v2"
 
@@ -18,20 +24,30 @@ const (
 	HTTPProxy  = "HTTP_PROXY"
 	HTTPSProxy = "HTTPS_PROXY"
 	NoProxy    = "NO_PROXY"
+
+	typeProxyConfigObserved  = "ProxyConfigObserved"
+	reasonProxyConfigApplied = "ProxyConfigApplied"
+	reasonProxyNotFound      = "ProxyNotFound"
+	reasonProxyUnreadable    = "ProxyUnreadable"
 )
 
-func NewProxyController(name string, proxyClient *clients.ProxyClient, operatorClient *clients.OperatorClient, eventRecorder events.Recorder) factory.Controller {
+// NewProxyController returns a controller that propagates the cluster-wide proxy
+// configuration to the operator environment, and maintains an informational condition
+// reporting whether it was observed.
+func NewProxyController(name string, proxyClient *clients.ProxyClient, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
 	c := proxyController{
-		name:        name,
-		proxyClient: proxyClient,
+		name:           name,
+		proxyClient:    proxyClient,
+		operatorClient: operatorClient,
 	}
 
-	return factory.New().WithSync(c.sync).WithSyncDegradedOnError(operatorClient).WithInformers(proxyClient.Informer()).ToController(name, eventRecorder)
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(proxyClient.Informer()).ToController(name, eventRecorder)
 }
 
 type proxyController struct {
-	name        string
-	proxyClient *clients.ProxyClient
+	name           string
+	proxyClient    clients.ProxyClientInterface
+	operatorClient v1helpers.OperatorClient
 }
 
 func (c *proxyController) sync(ctx context.Context, _ factory.SyncContext) error {
@@ -39,10 +55,21 @@ func (c *proxyController) sync(ctx context.Context, _ factory.SyncContext) error
 	logger.Info("sync started")
 	defer logger.Info("sync finished")
 
-	return UpdateProxyEnvironment(logger, c.proxyClient)
+	status, err := updateProxyEnvironment(logger, c.proxyClient)
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(proxyConfigObservedCondition(status, err))); updateErr != nil {
+		return updateErr
+	}
+	return err
 }
 
 func UpdateProxyEnvironment(logger logr.Logger, pc clients.ProxyClientInterface) error {
+	_, err := updateProxyEnvironment(logger, pc)
+	return err
+}
+
+// updateProxyEnvironment updates the environment from the cluster-wide proxy
+// configuration, and returns the proxy status applied, or nil if there is no Proxy.
+func updateProxyEnvironment(logger logr.Logger, pc clients.ProxyClientInterface) (*configv1.ProxyStatus, error) {
 	logger.Info("getting cluster-wide proxy configuration")
 	proxySpec, err := pc.Get("cluster")
 	if err != nil {
@@ -53,9 +80,9 @@ func UpdateProxyEnvironment(logger logr.Logger, pc clients.ProxyClientInterface)
 				HTTPSProxy: "",
 				NoProxy:    "",
 			})
-			return nil
+			return nil, nil
 		}
-		return fmt.Errorf("failed to get proxy: %w", err)
+		return nil, fmt.Errorf("failed to get proxy: %w", err)
 	}
 
 	logger.Info("updating environment")
@@ -64,7 +91,52 @@ func UpdateProxyEnvironment(logger logr.Logger, pc clients.ProxyClientInterface)
 		HTTPSProxy: proxySpec.Status.HTTPSProxy,
 		NoProxy:    proxySpec.Status.NoProxy,
 	})
-	return nil
+	return &proxySpec.Status, nil
+}
+
+// proxyConfigObservedCondition reports the outcome of updateProxyEnvironment, along
+// with the applied proxy settings with their credentials redacted.
+func proxyConfigObservedCondition(status *configv1.ProxyStatus, err error) operatorv1.OperatorCondition {
+	switch {
+	case err != nil:
+		return operatorv1.OperatorCondition{
+			Type:    typeProxyConfigObserved,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  reasonProxyUnreadable,
+			Message: fmt.Sprintf("the cluster proxy configuration could not be read, the previous configuration is kept: %v", err),
+		}
+	case status == nil:
+		return operatorv1.OperatorCondition{
+			Type:    typeProxyConfigObserved,
+			Status:  operatorv1.ConditionFalse,
+			Reason:  reasonProxyNotFound,
+			Message: "the cluster Proxy was not found, no proxy configuration is applied",
+		}
+	default:
+		return operatorv1.OperatorCondition{
+			Type:    typeProxyConfigObserved,
+			Status:  operatorv1.ConditionTrue,
+			Reason:  reasonProxyConfigApplied,
+			Message: fmt.Sprintf("applied the cluster proxy configuration %s=%q, %s=%q, %s=%q", HTTPProxy, redactProxyURL(status.HTTPProxy), HTTPSProxy, redactProxyURL(status.HTTPSProxy), NoProxy, status.NoProxy),
+		}
+	}
+}
+
+// redactProxyURL redacts the user information of a proxy URL. A value that cannot be
+// parsed is redacted entirely if it may hold credentials.
+func redactProxyURL(proxyURL string) string {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		if strings.Contains(proxyURL, "@") {
+			return redactedValue
+		}
+		return proxyURL
+	}
+	if u.User == nil {
+		return proxyURL
+	}
+	u.User = url.User(redactedValue)
+	return u.String()
 }
 
 // Updates the local environment and returns true if changed, false if unchanged
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	// proxyTrustedCABundleConfigMap is created in the operand namespaces when the cluster
+	// proxy has a trustedCA. The cluster-network-operator injects into it the trust bundle
+	// made of the system CAs and of the proxy trustedCA ConfigMap of openshift-config.
+	proxyTrustedCABundleConfigMap = "olm-proxy-trusted-ca-bundle"
+	trustedCABundleInjectionLabel = "config.openshift.io/inject-trusted-cabundle"
+	trustedCABundleKey            = "ca-bundle.crt"
+
+	proxyTrustedCAVolume    = "olm-proxy-trusted-ca-bundle"
+	proxyTrustedCAMountPath = "/var/run/olm-proxy-trusted-ca"
+
+	// SSLCertDir lists the directories Go reads CA certificates from, in addition to the
+	// system bundle file.
+	SSLCertDir = "SSL_CERT_DIR"
+)
+
+// NewProxyTrustedCAController returns a controller creating, in each of the namespaces,
+// the ConfigMap the proxy trust bundle is injected into, as long as the cluster proxy
+// has a trustedCA.
+func NewProxyTrustedCAController(name string, namespaces []string, proxyClient *clients.ProxyClient, kubeClient corev1client.ConfigMapsGetter, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &proxyTrustedCAController{
+		name:        name,
+		namespaces:  namespaces,
+		proxyClient: proxyClient,
+		kubeClient:  kubeClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(proxyClient.Informer()).ToController(name, eventRecorder)
+}
+
+type proxyTrustedCAController struct {
+	name        string
+	namespaces  []string
+	proxyClient clients.ProxyClientInterface
+	kubeClient  corev1client.ConfigMapsGetter
+}
+
+func (c *proxyTrustedCAController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	proxyConfig, err := c.proxyClient.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting proxies.config.openshift.io/cluster: %w", err)
+	}
+	if proxyConfig.Spec.TrustedCA.Name == "" {
+		return nil
+	}
+
+	for _, namespace := range c.namespaces {
+		required := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      proxyTrustedCABundleConfigMap,
+				Labels:    map[string]string{trustedCABundleInjectionLabel: "true"},
+			},
+		}
+		// the injected bundle is preserved as the required ConfigMap does not set it
+		if _, _, err := resourceapply.ApplyConfigMap(ctx, c.kubeClient, syncCtx.Recorder(), required); err != nil {
+			return fmt.Errorf("applying ConfigMap %s/%s: %w", namespace, proxyTrustedCABundleConfigMap, err)
+		}
+	}
+	return nil
+}
+
+// addProxyTrustedCAVolume adds to the pod the volume of the injected proxy trust bundle.
+// The volume is optional so that pods start before the bundle is injected.
+func addProxyTrustedCAVolume(podSpec *corev1.PodSpec) {
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == proxyTrustedCAVolume {
+			return
+		}
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: proxyTrustedCAVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: proxyTrustedCABundleConfigMap},
+				Items:                []corev1.KeyToPath{{Key: trustedCABundleKey, Path: "tls-ca-bundle.pem"}},
+				Optional:             ptr.To(true),
+			},
+		},
+	})
+}
+
+// mountProxyTrustedCA mounts the proxy trust bundle into the container and adds its
+// directory to those the container reads CA certificates from.
+func mountProxyTrustedCA(con *corev1.Container) {
+	mounted := false
+	for _, mount := range con.VolumeMounts {
+		if mount.Name == proxyTrustedCAVolume {
+			mounted = true
+			break
+		}
+	}
+	if !mounted {
+		con.VolumeMounts = append(con.VolumeMounts, corev1.VolumeMount{Name: proxyTrustedCAVolume, MountPath: proxyTrustedCAMountPath, ReadOnly: true})
+	}
+
+	for i := range con.Env {
+		if con.Env[i].Name != SSLCertDir {
+			continue
+		}
+		dirs := strings.Split(con.Env[i].Value, ":")
+		for _, dir := range dirs {
+			if dir == proxyTrustedCAMountPath {
+				return
+			}
+		}
+		con.Env[i].Value = strings.Join(append(dirs, proxyTrustedCAMountPath), ":")
+		return
+	}
+	con.Env = append(con.Env, corev1.EnvVar{Name: SSLCertDir, Value: proxyTrustedCAMountPath})
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestProxyTrustedCAControllerSync(t *testing.T) {
+	ctx := context.Background()
+	// the bundle injected by the cluster-network-operator is kept
+	injected := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openshift-catalogd",
+			Name:      proxyTrustedCABundleConfigMap,
+			Labels:    map[string]string{trustedCABundleInjectionLabel: "true"},
+		},
+		Data: map[string]string{"ca-bundle.crt": "injected"},
+	}
+	kubeClient := kubefake.NewSimpleClientset(injected)
+	mpc := &MockProxyClient{}
+	c := &proxyTrustedCAController{
+		name:        "test",
+		namespaces:  []string{"openshift-catalogd", "openshift-operator-controller"},
+		proxyClient: mpc,
+		kubeClient:  kubeClient.CoreV1(),
+	}
+	syncCtx := factory.NewSyncContext("test", events.NewInMemoryRecorder("test"))
+
+	if err := c.sync(ctx, syncCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := kubeClient.CoreV1().ConfigMaps("openshift-operator-controller").Get(ctx, proxyTrustedCABundleConfigMap, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected no ConfigMap without a trustedCA, got %v", err)
+	}
+
+	mpc.Proxy = configv1.Proxy{Spec: configv1.ProxySpec{TrustedCA: configv1.ConfigMapNameReference{Name: "user-ca-bundle"}}}
+	if err := c.sync(ctx, syncCtx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, namespace := range c.namespaces {
+		cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, proxyTrustedCABundleConfigMap, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cm.Labels[trustedCABundleInjectionLabel] != "true" {
+			t.Errorf("expected ConfigMap %s/%s to request the trust bundle injection, got labels %v", namespace, cm.Name, cm.Labels)
+		}
+	}
+	cm, err := kubeClient.CoreV1().ConfigMaps("openshift-catalogd").Get(ctx, proxyTrustedCABundleConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cm.Data["ca-bundle.crt"] != "injected" {
+		t.Errorf("expected the injected bundle to be kept, got %v", cm.Data)
+	}
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"sort"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/openshift/library-go/pkg/operator/status"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// operandNamespaceSelector matches the namespaces carrying the label the
+// operand manifests apply to every resource they own.
+var operandNamespaceSelector = labels.SelectorFromSet(labels.Set{"app.kubernetes.io/part-of": "olm"})
+
+// NewOperandNamespacesRelatedObjectsFunc returns a RelatedObjectsFunc that reports
+// every namespace observed to be owned by an operand, so that namespaces created
+// at runtime are added to the ClusterOperator relatedObjects without a restart.
+// The status controller merges the static relatedObjects into the result.
+func NewOperandNamespacesRelatedObjectsFunc(namespaceInformer corev1informers.NamespaceInformer) status.RelatedObjectsFunc {
+	return operandNamespacesRelatedObjectsFunc(namespaceInformer.Informer().HasSynced, namespaceInformer.Lister())
+}
+
+func operandNamespacesRelatedObjectsFunc(hasSynced cache.InformerSynced, namespaceLister corev1listers.NamespaceLister) status.RelatedObjectsFunc {
+	return func() (bool, []configv1.ObjectReference) {
+		if !hasSynced() {
+			return false, nil
+		}
+		namespaces, err := namespaceLister.List(operandNamespaceSelector)
+		if err != nil {
+			return false, nil
+		}
+		relatedObjects := make([]configv1.ObjectReference, 0, len(namespaces))
+		for _, ns := range namespaces {
+			relatedObjects = append(relatedObjects, configv1.ObjectReference{
+				Group:    "",
+				Resource: "namespaces",
+				Name:     ns.Name,
+			})
+		}
+		sort.Slice(relatedObjects, func(i, j int) bool {
+			return relatedObjects[i].Name < relatedObjects[j].Name
+		})
+		return true, relatedObjects
+	}
+}
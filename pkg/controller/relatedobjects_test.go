@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func operandNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func namespaceReference(name string) configv1.ObjectReference {
+	return configv1.ObjectReference{Resource: "namespaces", Name: name}
+}
+
+func TestOperandNamespacesRelatedObjectsFunc(t *testing.T) {
+	partOfOLM := map[string]string{"app.kubernetes.io/part-of": "olm"}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*corev1.Namespace{
+		operandNamespace("openshift-catalogd", partOfOLM),
+		operandNamespace("openshift-operator-controller", partOfOLM),
+		operandNamespace("default", nil),
+	} {
+		if err := indexer.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	synced := false
+	relatedObjectsFunc := operandNamespacesRelatedObjectsFunc(func() bool { return synced }, corev1listers.NewNamespaceLister(indexer))
+
+	if isSet, _ := relatedObjectsFunc(); isSet {
+		t.Fatal("expected relatedObjects to be unset before the informer has synced")
+	}
+
+	synced = true
+	isSet, relatedObjects := relatedObjectsFunc()
+	if !isSet {
+		t.Fatal("expected relatedObjects to be set")
+	}
+	expected := []configv1.ObjectReference{
+		namespaceReference("openshift-catalogd"),
+		namespaceReference("openshift-operator-controller"),
+	}
+	if !reflect.DeepEqual(expected, relatedObjects) {
+		t.Fatalf("expected relatedObjects %v, got %v", expected, relatedObjects)
+	}
+
+	// A namespace created by an operand at runtime is picked up on the next call.
+	if err := indexer.Add(operandNamespace("openshift-olm-extensions", partOfOLM)); err != nil {
+		t.Fatal(err)
+	}
+	_, relatedObjects = relatedObjectsFunc()
+	expected = []configv1.ObjectReference{
+		namespaceReference("openshift-catalogd"),
+		namespaceReference("openshift-olm-extensions"),
+		namespaceReference("openshift-operator-controller"),
+	}
+	if !reflect.DeepEqual(expected, relatedObjects) {
+		t.Fatalf("expected relatedObjects %v, got %v", expected, relatedObjects)
+	}
+}
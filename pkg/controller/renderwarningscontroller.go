@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	typeOperandRenderWarnings = "OperandRenderWarnings"
+	reasonRenderWarnings      = "RenderWarnings"
+	reasonNoRenderWarnings    = "NoRenderWarnings"
+)
+
+// NewRenderWarningsController returns a controller that maintains an informational
+// condition listing the non-fatal problems found while rendering the operands.
+func NewRenderWarningsController(name string, warnings []string, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &renderWarningsController{
+		name:           name,
+		warnings:       warnings,
+		operatorClient: operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer()).ToController(name, eventRecorder)
+}
+
+type renderWarningsController struct {
+	name           string
+	warnings       []string
+	operatorClient v1helpers.OperatorClient
+}
+
+func (c *renderWarningsController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	cond := operatorv1.OperatorCondition{
+		Type:   typeOperandRenderWarnings,
+		Status: operatorv1.ConditionFalse,
+		Reason: reasonNoRenderWarnings,
+	}
+	if len(c.warnings) > 0 {
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = reasonRenderWarnings
+		cond.Message = fmt.Sprintf("the operands were rendered with warnings: %s", strings.Join(c.warnings, "; "))
+	}
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+func TestRenderWarnings(t *testing.T) {
+	b := Builder{
+		Assets: fstest.MapFS{
+			"operator-controller/deployment.yaml": &fstest.MapFile{Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  namespace: openshift-operator-controller
+  name: operator-controller-controller-manager
+`)},
+		},
+		OperandConfigs: OperandConfigs{
+			"operator-controler": {PriorityClassName: "system-cluster-critical"},
+			"operator-controller": {ClusterCatalogs: map[string]ClusterCatalogConfig{
+				"openshift-redhat-operators": {},
+			}},
+		},
+		ExcludedManifests: []string{"operator-controller/deployment.yaml", "catalogd/*"},
+	}
+	if _, _, _, _, err := b.BuildControllers("operator-controller"); err != nil {
+		t.Fatalf("expected the warnings not to fail the render, got: %v", err)
+	}
+	expected := []string{
+		`the excluded manifest pattern "catalogd/*" matches no manifest`,
+		`the operand config of ClusterCatalog "openshift-redhat-operators" is ignored, component "operator-controller" does not render it`,
+		`the operand config of unknown component "operator-controler" is ignored`,
+	}
+	if !reflect.DeepEqual(expected, b.RenderWarnings()) {
+		t.Fatalf("expected warnings %q, got %q", expected, b.RenderWarnings())
+	}
+
+	for _, tc := range []struct {
+		name            string
+		warnings        []string
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:            "render warnings",
+			warnings:        b.RenderWarnings(),
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonRenderWarnings,
+			expectedMessage: "the operands were rendered with warnings: " + expected[0] + "; " + expected[1] + "; " + expected[2],
+		},
+		{
+			name:           "no render warnings",
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonNoRenderWarnings,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &renderWarningsController{name: "test", warnings: tc.warnings, operatorClient: operatorClient}
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeOperandRenderWarnings, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
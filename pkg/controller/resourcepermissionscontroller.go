@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	ocv1 "github.com/operator-framework/operator-controller/api/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/cluster-olm-operator/pkg/clients"
+)
+
+const (
+	typeResourcePermissionsDegraded  = "ResourcePermissionsDegraded"
+	reasonMissingResourcePermissions = "MissingResourcePermissions"
+
+	resourcePermissionsResyncInterval = 10 * time.Minute
+)
+
+var (
+	// resourceApplyVerbs are the verbs resourceapply, which applies the static
+	// resources and Deployments, needs to apply a rendered resource.
+	resourceApplyVerbs = []string{"get", "create", "update"}
+	// serverSideApplyVerbs are the verbs the ClusterCatalogs, applied with
+	// server-side apply patches, need.
+	serverSideApplyVerbs = []string{"patch"}
+)
+
+// NewResourcePermissionsController returns a controller reporting a degraded condition
+// when the operator is not allowed, according to SelfSubjectAccessReviews, to apply
+// the rendered resources, instead of failing later for each resource. Each resource
+// is reviewed by name, as permissions can be granted for some names only.
+func NewResourcePermissionsController(name string, relatedObjects []configv1.ObjectReference, authorizationClient authorizationv1client.SelfSubjectAccessReviewsGetter, operatorClient *clients.OperatorClient, eventRecorder events.Recorder, slowSyncThreshold time.Duration) factory.Controller {
+	c := &resourcePermissionsController{
+		name:                name,
+		resources:           permissionResources(relatedObjects),
+		authorizationClient: authorizationClient,
+		operatorClient:      operatorClient,
+	}
+
+	return factory.New().WithSync(instrumentSync(name, slowSyncThreshold, c.sync)).WithSyncDegradedOnError(operatorClient).WithInformers(operatorClient.Informer()).ResyncEvery(resourcePermissionsResyncInterval).ToController(name, eventRecorder)
+}
+
+type resourcePermissionsController struct {
+	name                string
+	resources           []permissionResource
+	authorizationClient authorizationv1client.SelfSubjectAccessReviewsGetter
+	operatorClient      v1helpers.OperatorClient
+}
+
+// permissionResource is a rendered resource the operator applies with verbs.
+type permissionResource struct {
+	group     string
+	resource  string
+	namespace string
+	name      string
+	verbs     []string
+}
+
+func (r permissionResource) String() string {
+	resource := r.resource
+	if r.group != "" {
+		resource += "." + r.group
+	}
+	if r.namespace == "" {
+		return fmt.Sprintf("%s %s", resource, r.name)
+	}
+	return fmt.Sprintf("%s %s/%s", resource, r.namespace, r.name)
+}
+
+// permissionResources returns the distinct resources of the given related objects,
+// along with the verbs they are applied with, sorted.
+func permissionResources(relatedObjects []configv1.ObjectReference) []permissionResource {
+	distinct := sets.New[configv1.ObjectReference]()
+	resources := make([]permissionResource, 0, len(relatedObjects))
+	for _, obj := range relatedObjects {
+		key := configv1.ObjectReference{Group: obj.Group, Resource: obj.Resource, Namespace: obj.Namespace, Name: obj.Name}
+		if distinct.Has(key) {
+			continue
+		}
+		distinct.Insert(key)
+		verbs := resourceApplyVerbs
+		if obj.Group == ocv1.GroupVersion.Group && obj.Resource == "clustercatalogs" {
+			verbs = serverSideApplyVerbs
+		}
+		resources = append(resources, permissionResource{group: obj.Group, resource: obj.Resource, namespace: obj.Namespace, name: obj.Name, verbs: verbs})
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].String() < resources[j].String()
+	})
+	return resources
+}
+
+func (c *resourcePermissionsController) sync(ctx context.Context, _ factory.SyncContext) error {
+	logger := klog.FromContext(ctx).WithName(c.name)
+	logger.V(4).Info("sync started")
+	defer logger.V(4).Info("sync finished")
+
+	var missing []string
+	for _, resource := range c.resources {
+		var denied []string
+		for _, verb := range resource.verbs {
+			// creates are authorized before the name is known, so rules limited to
+			// some names never grant them
+			name := resource.name
+			if verb == "create" {
+				name = ""
+			}
+			review, err := c.authorizationClient.SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: resource.namespace,
+						Verb:      verb,
+						Group:     resource.group,
+						Resource:  resource.resource,
+						Name:      name,
+					},
+				},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("reviewing access to %s %s: %w", verb, resource, err)
+			}
+			if !review.Status.Allowed {
+				denied = append(denied, verb)
+			}
+		}
+		if len(denied) > 0 {
+			missing = append(missing, fmt.Sprintf("%s %s", strings.Join(denied, ","), resource))
+		}
+	}
+
+	cond := operatorv1.OperatorCondition{
+		Type:   typeResourcePermissionsDegraded,
+		Status: operatorv1.ConditionFalse,
+		Reason: reasonAsExpected,
+	}
+	if len(missing) > 0 {
+		logger.Info("missing permissions to apply the rendered resources", "missing", missing)
+		cond.Status = operatorv1.ConditionTrue
+		cond.Reason = reasonMissingResourcePermissions
+		cond.Message = fmt.Sprintf("the operator is not allowed to apply the rendered resources: %s", strings.Join(missing, "; "))
+	}
+	if _, _, updateErr := v1helpers.UpdateStatus(ctx, c.operatorClient, v1helpers.UpdateConditionFn(cond)); updateErr != nil {
+		return updateErr
+	}
+	return nil
+}
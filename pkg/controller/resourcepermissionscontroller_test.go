@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/v1helpers"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// denyingAuthorizationClient returns a fake client allowing every SelfSubjectAccessReview
+// but those the deny func matches.
+func denyingAuthorizationClient(deny func(*authorizationv1.ResourceAttributes) bool) *kubefake.Clientset {
+	kubeClient := kubefake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+		review.Status.Allowed = !deny(review.Spec.ResourceAttributes)
+		return true, review, nil
+	})
+	return kubeClient
+}
+
+func TestResourcePermissionsControllerSync(t *testing.T) {
+	relatedObjects := []configv1.ObjectReference{
+		{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions", Name: "clustercatalogs.olm.operatorframework.io"},
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+		{Group: "apps", Resource: "deployments", Namespace: "openshift-operator-controller", Name: "operator-controller-controller-manager"},
+		{Resource: "serviceaccounts", Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+		{Resource: "serviceaccounts", Namespace: "openshift-catalogd", Name: "other"},
+		{Resource: "serviceaccounts", Namespace: "openshift-catalogd", Name: "other"},
+		{Group: "olm.operatorframework.io", Resource: "clustercatalogs", Name: "openshift-certified-operators"},
+	}
+
+	for _, tc := range []struct {
+		name            string
+		deny            func(*authorizationv1.ResourceAttributes) bool
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:           "all permissions granted",
+			deny:           func(*authorizationv1.ResourceAttributes) bool { return false },
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name: "resource denied in one namespace",
+			deny: func(attrs *authorizationv1.ResourceAttributes) bool {
+				return attrs.Group == "apps" && attrs.Resource == "deployments" && attrs.Namespace == "openshift-operator-controller" && attrs.Verb != "get"
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonMissingResourcePermissions,
+			expectedMessage: "the operator is not allowed to apply the rendered resources: create,update deployments.apps openshift-operator-controller/operator-controller-controller-manager",
+		},
+		{
+			name: "cluster-scoped and core resources denied",
+			deny: func(attrs *authorizationv1.ResourceAttributes) bool {
+				return attrs.Resource == "customresourcedefinitions" && attrs.Verb == "update" || attrs.Resource == "serviceaccounts" && attrs.Name == "other"
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonMissingResourcePermissions,
+			expectedMessage: "the operator is not allowed to apply the rendered resources: update customresourcedefinitions.apiextensions.k8s.io clustercatalogs.olm.operatorframework.io; get,update serviceaccounts openshift-catalogd/other",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := denyingAuthorizationClient(tc.deny)
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &resourcePermissionsController{
+				name:                "test",
+				resources:           permissionResources(relatedObjects),
+				authorizationClient: kubeClient.AuthorizationV1(),
+				operatorClient:      operatorClient,
+			}
+
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// one review per resourceapply verb for each of the 5 distinct static resources,
+			// and a patch review for the ClusterCatalog
+			if expected, reviews := 5*len(resourceApplyVerbs)+1, len(kubeClient.Actions()); reviews != expected {
+				t.Errorf("expected %d access reviews, got %d", expected, reviews)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeResourcePermissionsDegraded, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
+
+// clusterRoleAuthorizationClient returns a fake client answering SelfSubjectAccessReviews
+// with the rules of the ClusterRole the operator is shipped with.
+func clusterRoleAuthorizationClient(t *testing.T) *kubefake.Clientset {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "manifests", "0000_51_olm_02_operator_clusterrole.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var clusterRole rbacv1.ClusterRole
+	if err := yaml.Unmarshal(data, &clusterRole); err != nil {
+		t.Fatal(err)
+	}
+	return denyingAuthorizationClient(func(attrs *authorizationv1.ResourceAttributes) bool {
+		for _, rule := range clusterRole.Rules {
+			if slices.Contains(rule.APIGroups, attrs.Group) && slices.Contains(rule.Resources, attrs.Resource) && slices.Contains(rule.Verbs, attrs.Verb) &&
+				(len(rule.ResourceNames) == 0 || slices.Contains(rule.ResourceNames, attrs.Name)) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestResourcePermissionsControllerShippedClusterRole(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		relatedObjects  []configv1.ObjectReference
+		expectedStatus  operatorv1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name: "rendered resources",
+			relatedObjects: []configv1.ObjectReference{
+				{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions", Name: "clustercatalogs.olm.operatorframework.io"},
+				{Group: "apps", Resource: "deployments", Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+				{Group: "olm.operatorframework.io", Resource: "clustercatalogs", Name: "openshift-certified-operators"},
+				{Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: "catalogd-manager-role"},
+				{Resource: "configmaps", Namespace: "openshift-operator-controller", Name: "operator-controller-openshift-ca"},
+				{Resource: "namespaces", Name: "openshift-catalogd"},
+				{Resource: "serviceaccounts", Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+			},
+			expectedStatus: operatorv1.ConditionFalse,
+			expectedReason: reasonAsExpected,
+		},
+		{
+			name: "ConfigMap not granted by name",
+			relatedObjects: []configv1.ObjectReference{
+				{Resource: "configmaps", Namespace: "openshift-operator-controller", Name: "other"},
+			},
+			expectedStatus:  operatorv1.ConditionTrue,
+			expectedReason:  reasonMissingResourcePermissions,
+			expectedMessage: "the operator is not allowed to apply the rendered resources: update configmaps openshift-operator-controller/other",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			operatorClient := v1helpers.NewFakeOperatorClient(&operatorv1.OperatorSpec{ManagementState: operatorv1.Managed}, &operatorv1.OperatorStatus{}, nil)
+			c := &resourcePermissionsController{
+				name:                "test",
+				resources:           permissionResources(tc.relatedObjects),
+				authorizationClient: clusterRoleAuthorizationClient(t).AuthorizationV1(),
+				operatorClient:      operatorClient,
+			}
+
+			if err := c.sync(context.Background(), nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assertOperatorCondition(t, operatorClient, typeResourcePermissionsDegraded, tc.expectedStatus, tc.expectedReason, tc.expectedMessage)
+		})
+	}
+}
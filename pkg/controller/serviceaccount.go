@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// requireServiceAccountHook holds the Deployment back until the ServiceAccount its
+// pods run as exists, so that the operand pods are not created before the static
+// resource controllers applied it. The failed sync is retried by the controller,
+// and the creation of the ServiceAccount triggers a new one.
+func requireServiceAccountHook(serviceAccountLister corev1listers.ServiceAccountLister) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		name := deployment.Spec.Template.Spec.ServiceAccountName
+		if !requiresServiceAccount(name) {
+			return nil
+		}
+		_, err := serviceAccountLister.ServiceAccounts(deployment.Namespace).Get(name)
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("waiting for ServiceAccount %s/%s used by Deployment %q to be created", deployment.Namespace, name, deployment.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("error getting ServiceAccount %s/%s: %w", deployment.Namespace, name, err)
+		}
+		return nil
+	}
+}
+
+// requiresServiceAccount returns whether the Deployment has to wait for the named
+// ServiceAccount. The default ServiceAccount is created along with the namespace.
+func requiresServiceAccount(name string) bool {
+	return name != "" && name != "default"
+}
+
+// requiredServiceAccountInformer returns the ServiceAccount informer of the namespace
+// of the Deployment manifest, delivering to the Deployment controller only the events
+// of the ServiceAccount its pods run as.
+func requiredServiceAccountInformer(informer cache.SharedIndexInformer, deployment *unstructured.Unstructured) *filteredInformer {
+	name, _, _ := unstructured.NestedString(deployment.Object, "spec", "template", "spec", "serviceAccountName")
+	return &filteredInformer{
+		SharedIndexInformer: informer,
+		filter: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return false
+			}
+			return requiresServiceAccount(name) && accessor.GetNamespace() == deployment.GetNamespace() && accessor.GetName() == name
+		},
+	}
+}
+
+// filteredInformer only delivers to its event handlers the events of the objects
+// accepted by the filter.
+type filteredInformer struct {
+	cache.SharedIndexInformer
+	filter func(obj interface{}) bool
+}
+
+func (i *filteredInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	return i.SharedIndexInformer.AddEventHandler(cache.FilteringResourceEventHandler{FilterFunc: i.filter, Handler: handler})
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestRequireServiceAccountHook(t *testing.T) {
+	deploymentWithServiceAccount := func(serviceAccountName string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{ServiceAccountName: serviceAccountName},
+				},
+			},
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	hook := requireServiceAccountHook(corev1listers.NewServiceAccountLister(indexer))
+
+	// The hook keeps failing until the ServiceAccount is created
+	err := hook(nil, deploymentWithServiceAccount("catalogd-controller-manager"))
+	if err == nil || !strings.Contains(err.Error(), "waiting for ServiceAccount openshift-catalogd/catalogd-controller-manager") {
+		t.Fatalf("expected an error waiting for the ServiceAccount, got %v", err)
+	}
+
+	if err := indexer.Add(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-catalogd", Name: "catalogd-controller-manager"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := hook(nil, deploymentWithServiceAccount("catalogd-controller-manager")); err != nil {
+		t.Fatalf("unexpected error once the ServiceAccount exists: %v", err)
+	}
+
+	for _, name := range []string{"", "default"} {
+		if err := hook(nil, deploymentWithServiceAccount(name)); err != nil {
+			t.Errorf("unexpected error for ServiceAccount %q: %v", name, err)
+		}
+	}
+}
+
+func TestRequiredServiceAccountInformer(t *testing.T) {
+	serviceAccount := func(namespace, name string) *corev1.ServiceAccount {
+		return &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	deployment := func(serviceAccountName string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"namespace": "openshift-catalogd", "name": "catalogd-controller-manager"},
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"serviceAccountName": serviceAccountName,
+			}}},
+		}}
+	}
+	informer := requiredServiceAccountInformer(cache.NewSharedIndexInformer(nil, &corev1.ServiceAccount{}, time.Minute, cache.Indexers{}), deployment("catalogd-controller-manager"))
+
+	for _, tc := range []struct {
+		name     string
+		obj      interface{}
+		expected bool
+	}{
+		{
+			name:     "required ServiceAccount",
+			obj:      serviceAccount("openshift-catalogd", "catalogd-controller-manager"),
+			expected: true,
+		},
+		{
+			name: "deleted required ServiceAccount",
+			obj: cache.DeletedFinalStateUnknown{
+				Key: "openshift-catalogd/catalogd-controller-manager",
+				Obj: serviceAccount("openshift-catalogd", "catalogd-controller-manager"),
+			},
+			expected: true,
+		},
+		{
+			name: "other ServiceAccount",
+			obj:  serviceAccount("openshift-catalogd", "default"),
+		},
+		{
+			name: "same name in another namespace",
+			obj:  serviceAccount("openshift-operator-controller", "catalogd-controller-manager"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := informer.filter(tc.obj); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+
+	if requiredServiceAccountInformer(nil, deployment("default")).filter(serviceAccount("openshift-catalogd", "default")) {
+		t.Error("expected no events for a Deployment running as the default ServiceAccount")
+	}
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+// DefaultSlowSyncThreshold is the default sync duration above which a controller logs
+// a warning.
+const DefaultSlowSyncThreshold = 10 * time.Second
+
+var syncDuration = metrics.NewHistogramVec(
+	&metrics.HistogramOpts{
+		Namespace:      "cluster_olm_operator",
+		Name:           "controller_sync_duration_seconds",
+		Help:           "Duration of controller syncs in seconds.",
+		Buckets:        metrics.ExponentialBuckets(0.005, 2, 14),
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"controller"},
+)
+
+func init() {
+	legacyregistry.MustRegister(syncDuration)
+}
+
+// instrumentSync wraps sync so that the duration of every sync is recorded,
+// and a warning is logged when it exceeds slowSyncThreshold. Zero disables the warning.
+// The static resource and Deployment controllers of library-go run their own sync
+// functions, so they are not instrumented.
+func instrumentSync(name string, slowSyncThreshold time.Duration, sync factory.SyncFunc) factory.SyncFunc {
+	return func(ctx context.Context, syncCtx factory.SyncContext) error {
+		start := time.Now()
+		err := sync(ctx, syncCtx)
+		observeSyncDuration(klog.FromContext(ctx).WithName(name), name, time.Since(start), slowSyncThreshold)
+		return err
+	}
+}
+
+func observeSyncDuration(logger logr.Logger, name string, duration, threshold time.Duration) {
+	syncDuration.WithLabelValues(name).Observe(duration.Seconds())
+	if threshold > 0 && duration > threshold {
+		logger.Info("sync exceeded the slow sync threshold", "duration", duration, "threshold", threshold)
+	}
+}
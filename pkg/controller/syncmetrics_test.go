@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"k8s.io/klog/v2"
+)
+
+func TestInstrumentSyncSlowSyncWarning(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		syncDuration  time.Duration
+		expectWarning bool
+	}{
+		{
+			name:          "slow sync",
+			syncDuration:  50 * time.Millisecond,
+			expectWarning: true,
+		},
+		{
+			name:          "fast sync",
+			expectWarning: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs []string
+			logger := funcr.New(func(prefix, args string) {
+				logs = append(logs, prefix+" "+args)
+			}, funcr.Options{})
+			ctx := klog.NewContext(context.Background(), logger)
+
+			sync := instrumentSync("TestController", 10*time.Millisecond, func(_ context.Context, _ factory.SyncContext) error {
+				time.Sleep(tc.syncDuration)
+				return nil
+			})
+			if err := sync(ctx, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			warned := strings.Contains(strings.Join(logs, "\n"), "sync exceeded the slow sync threshold")
+			if warned != tc.expectWarning {
+				t.Errorf("expected warning: %v, got logs: %v", tc.expectWarning, logs)
+			}
+		})
+	}
+}
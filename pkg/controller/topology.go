@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"fmt"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	"github.com/openshift/library-go/pkg/operator/deploymentcontroller"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+)
+
+// UpdateDeploymentTopologyHook adapts the Deployment to the control plane topology
+// reported by the cluster Infrastructure. On single-node clusters the Deployment is
+// scaled to a single replica without pod anti-affinity; otherwise the HA values from
+// the manifest are kept.
+func UpdateDeploymentTopologyHook(infrastructureLister configv1listers.InfrastructureLister) deploymentcontroller.DeploymentHookFunc {
+	return func(_ *operatorv1.OperatorSpec, deployment *appsv1.Deployment) error {
+		infra, err := infrastructureLister.Get("cluster")
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting infrastructures.config.openshift.io/cluster: %w", err)
+		}
+		if infra.Status.ControlPlaneTopology != configv1.SingleReplicaTopologyMode {
+			return nil
+		}
+
+		deployment.Spec.Replicas = ptr.To[int32](1)
+		if affinity := deployment.Spec.Template.Spec.Affinity; affinity != nil {
+			affinity.PodAntiAffinity = nil
+		}
+		return nil
+	}
+}
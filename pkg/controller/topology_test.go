@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+)
+
+func TestUpdateDeploymentTopologyHook(t *testing.T) {
+	for _, tc := range []struct {
+		name                    string
+		infrastructure          *configv1.Infrastructure
+		expectedReplicas        int32
+		expectedPodAntiAffinity bool
+	}{
+		{
+			name:                    "single node",
+			infrastructure:          infrastructureWithTopology(configv1.SingleReplicaTopologyMode),
+			expectedReplicas:        1,
+			expectedPodAntiAffinity: false,
+		},
+		{
+			name:                    "highly available",
+			infrastructure:          infrastructureWithTopology(configv1.HighlyAvailableTopologyMode),
+			expectedReplicas:        2,
+			expectedPodAntiAffinity: true,
+		},
+		{
+			name:                    "infrastructure not found",
+			expectedReplicas:        2,
+			expectedPodAntiAffinity: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.infrastructure != nil {
+				if err := indexer.Add(tc.infrastructure); err != nil {
+					t.Fatal(err)
+				}
+			}
+			deployment := &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Replicas: ptr.To[int32](2),
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Affinity: &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{}},
+						},
+					},
+				},
+			}
+
+			hook := UpdateDeploymentTopologyHook(configv1listers.NewInfrastructureLister(indexer))
+			if err := hook(&operatorv1.OperatorSpec{}, deployment); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := ptr.Deref(deployment.Spec.Replicas, 0); actual != tc.expectedReplicas {
+				t.Errorf("expected %d replicas, got %d", tc.expectedReplicas, actual)
+			}
+			if actual := deployment.Spec.Template.Spec.Affinity.PodAntiAffinity != nil; actual != tc.expectedPodAntiAffinity {
+				t.Errorf("expected pod anti-affinity: %v, got: %v", tc.expectedPodAntiAffinity, actual)
+			}
+		})
+	}
+}
+
+func infrastructureWithTopology(topology configv1.TopologyMode) *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{ControlPlaneTopology: topology},
+	}
+}